@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+// Built-in Players that can be named in StartGameRequest.Bots.
+var botStrategies = map[string]func(r *rand.Rand) Player{
+	"random":    func(r *rand.Rand) Player { return &randomBot{r} },
+	"heuristic": func(*rand.Rand) Player { return heuristicBot{} },
+}
+
+func checkBotStrategy(strategy string) error {
+	if _, ok := botStrategies[strategy]; !ok {
+		return fmt.Errorf("unknown bot: %v", strategy)
+	}
+	return nil
+}
+
+// A built-in bot. Bots that use randomness always make the same choices for the same seed.
+func NewBot(strategy string, seed int64) (Player, error) {
+	err := checkBotStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+	return botStrategies[strategy](rand.New(rand.NewSource(seed))), nil
+}
+
+func botName(strategy string, i int) string {
+	return fmt.Sprintf("%v-bot-%v", strategy, i+1)
+}
+
+// Seat a bot. It doesn't move until lockingStartBots.
+func (g *Game) lockingJoinBot(strategy string, playerName string) error {
+	err := checkBotStrategy(strategy)
+	if err != nil {
+		return err
+	}
+	session, err := RandomSessionToken()
+	if err != nil {
+		return fmt.Errorf("error generating session token")
+	}
+	g.Lock()
+	defer g.Unlock()
+	return g.join(playerName, session, strategy, "")
+}
+
+// Requires game is locked!
+func (g *Game) addBot(strategy string, session SessionToken, seat int) {
+	// Seed from the deal and the seat so the same game plays out the same way,
+	// without every bot in it playing alike.
+	seed := int64(seat)
+	if g.Seed != nil {
+		seed += *g.Seed
+	} else if s, err := RandomSeed(); err == nil {
+		seed = s
+	}
+	if g.bots == nil {
+		g.bots = make(map[SessionToken]Player)
+	}
+	g.bots[session], _ = NewBot(strategy, seed)
+}
+
+func (g *Game) lockingStartBots() {
+	g.Lock()
+	defer g.Unlock()
+	for session, bot := range g.bots {
+		go g.runBot(session, bot)
+	}
+}
+
+// Move whenever it's the bot's turn, until the game is over.
+func (g *Game) runBot(session SessionToken, bot Player) {
+	for {
+		changed := g.lockingChanged()
+		state, err := g.getState(session, 0)
+		if err != nil {
+			log.Printf("Error: bot in game %v can't see the game: %v", g.Name, err)
+			return
+		}
+		if state.State.Over() {
+			return
+		}
+		if state.State == YourTurn {
+			move := bot.ChooseMove(&state)
+			err := g.lockingMove(session, move)
+			if now, _ := g.getState(session, 0); err != nil && now.TurnCursor != state.TurnCursor {
+				// The bot took too long and the turn timed out.
+				continue
+			}
+			if err != nil {
+				log.Printf("Error: bot %v in game %v made an illegal move %v: %v", state.WhoseTurn, g.Name, move, err)
+				// Discarding is always legal.
+				err = g.lockingMove(session, discardMove(state.Hand[0].ID))
+			}
+			if err != nil {
+				log.Printf("Error: bot %v in game %v can't move: %v", state.WhoseTurn, g.Name, err)
+				return
+			}
+			continue
+		}
+		<-changed
+	}
+}
+
+func playMove(cardID int) Move {
+	return Move{Type: Play, CardID: &cardID}
+}
+
+func discardMove(cardID int) Move {
+	return Move{Type: Discard, CardID: &cardID}
+}
+
+// Every hint that touches at least one card.
+func legalHints(state *GameStateSummary) []Move {
+	rules := lookupRuleSet(state.Variant)
+	var hints []Move
+	for _, player := range state.Players {
+		hand, ok := state.OtherHands[player]
+		if !ok {
+			continue
+		}
+		player := player
+		for _, color := range rules.Colors() {
+			if !rules.CanHintColor(color) {
+				continue
+			}
+			color := color
+			move := Move{Type: Hint, ToPlayer: &player, Color: &color}
+			for _, card := range hand {
+				if rules.ColorTouches(color, card) {
+					move.CardIDs = append(move.CardIDs, card.ID)
+				}
+			}
+			if len(move.CardIDs) > 0 {
+				hints = append(hints, move)
+			}
+		}
+		for _, number := range rules.Numbers() {
+			if !rules.CanHintNumber(number) {
+				continue
+			}
+			number := number
+			move := Move{Type: Hint, ToPlayer: &player, Number: &number}
+			for _, card := range hand {
+				if rules.NumberTouches(number, card) {
+					move.CardIDs = append(move.CardIDs, card.ID)
+				}
+			}
+			if len(move.CardIDs) > 0 {
+				hints = append(hints, move)
+			}
+		}
+	}
+	return hints
+}
+
+// Picks uniformly among all legal moves.
+type randomBot struct {
+	rand *rand.Rand
+}
+
+func (b *randomBot) ChooseMove(state *GameStateSummary) Move {
+	var moves []Move
+	for _, card := range state.Hand {
+		moves = append(moves, playMove(card.ID), discardMove(card.ID))
+	}
+	if state.Hints > 0 {
+		moves = append(moves, legalHints(state)...)
+	}
+	return moves[b.rand.Intn(len(moves))]
+}
+
+// Follows a simple convention: hints only ever point at a playable card, the
+// newest card the hint touches. So:
+//  1. Play a card we know is playable, or one that was pointed at.
+//  2. Otherwise point at someone's playable card, if there are hints left.
+//  3. Otherwise discard the oldest card no hint has touched.
+type heuristicBot struct{}
+
+// What a player has been told about a card in their hand.
+type cardKnowledge struct {
+	color   *Color
+	number  *int
+	touched bool // by any hint
+	pointed bool // as the newest card touched by a hint
+}
+
+func (heuristicBot) ChooseMove(state *GameStateSummary) Move {
+	rules := lookupRuleSet(state.Variant)
+	known := handKnowledge(state.Turns, state.WhoseTurn, state.Hand)
+
+	for _, card := range state.Hand {
+		k := known[card.ID]
+		if k.color != nil && k.number != nil && isPlayable(rules, state.Board, Card{Color: *k.color, Number: *k.number}) {
+			return playMove(card.ID)
+		}
+		if k.color == nil && k.number != nil && allPilesAt(state.Board, *k.number-1) {
+			return playMove(card.ID)
+		}
+	}
+	for _, card := range state.Hand {
+		k := known[card.ID]
+		if !k.pointed {
+			continue
+		}
+		// Skip it if what we know says it can't be played any more.
+		if k.color != nil && pileTop(state.Board, *k.color) >= len(rules.Numbers()) {
+			continue
+		}
+		if k.number != nil && k.color == nil && !anyPileAt(state.Board, *k.number-1) {
+			continue
+		}
+		return playMove(card.ID)
+	}
+
+	if state.Hints > 0 {
+		if hint, ok := pointingHint(state); ok {
+			return hint
+		}
+	}
+
+	for _, card := range state.Hand {
+		if !known[card.ID].touched {
+			return discardMove(card.ID)
+		}
+	}
+	return discardMove(state.Hand[0].ID)
+}
+
+// Replay the hints given to player and what they say about the cards still in hand.
+func handKnowledge(turns []Turn, player string, hand []HiddenCard) map[int]*cardKnowledge {
+	known := make(map[int]*cardKnowledge, len(hand))
+	for _, card := range hand {
+		known[card.ID] = &cardKnowledge{}
+	}
+	for _, turn := range turns {
+		move := turn.Move
+		if move.Type != Hint || move.ToPlayer == nil || *move.ToPlayer != player {
+			continue
+		}
+		for _, id := range move.CardIDs {
+			k, ok := known[id]
+			if !ok {
+				continue // no longer in hand
+			}
+			k.touched = true
+			if move.Number != nil {
+				number := *move.Number
+				k.number = &number
+			}
+			if move.Color != nil {
+				color := *move.Color
+				if k.color != nil && *k.color != color {
+					// Only rainbow cards are touched by two different colors.
+					color = Black
+				}
+				k.color = &color
+			}
+		}
+		if k, ok := known[newestCard(move.CardIDs)]; ok {
+			k.pointed = true
+		}
+	}
+	return known
+}
+
+// Cards are drawn in ID order, so the newest card has the highest ID.
+func newestCard(cardIDs []int) int {
+	newest := -1
+	for _, id := range cardIDs {
+		if id > newest {
+			newest = id
+		}
+	}
+	return newest
+}
+
+func pileTop(board map[Color][]Card, color Color) int {
+	pile := board[color]
+	if len(pile) == 0 {
+		return 0
+	}
+	return pile[len(pile)-1].Number
+}
+
+func isPlayable(rules RuleSet, board map[Color][]Card, card Card) bool {
+	pile, ok := board[card.Color]
+	return ok && pileTop(board, card.Color) == card.Number-1 && len(pile) < len(rules.Numbers())
+}
+
+func allPilesAt(board map[Color][]Card, number int) bool {
+	for color := range board {
+		if pileTop(board, color) != number {
+			return false
+		}
+	}
+	return true
+}
+
+func anyPileAt(board map[Color][]Card, number int) bool {
+	for color := range board {
+		if pileTop(board, color) == number {
+			return true
+		}
+	}
+	return false
+}
+
+// A hint whose newest touched card is playable, starting with the next player.
+// Prefers hints that touch fewer cards.
+func pointingHint(state *GameStateSummary) (Move, bool) {
+	rules := lookupRuleSet(state.Variant)
+	hints := legalHints(state)
+	for _, player := range playersAfter(state.Players, state.WhoseTurn) {
+		var best Move
+		found := false
+		for _, hint := range hints {
+			if *hint.ToPlayer != player {
+				continue
+			}
+			newest := newestCard(hint.CardIDs)
+			for _, card := range state.OtherHands[player] {
+				if card.ID != newest || !isPlayable(rules, state.Board, card) {
+					continue
+				}
+				if !found || len(hint.CardIDs) < len(best.CardIDs) {
+					best = hint
+					found = true
+				}
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return Move{}, false
+}
+
+// The other players, in turn order starting after player.
+func playersAfter(players []string, player string) []string {
+	var res []string
+	for i, p := range players {
+		if p == player {
+			res = append(res, players[i+1:]...)
+			res = append(res, players[:i]...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Wait for a game to finish, or fail the test.
+func waitForFinish(t *testing.T, game *Game) {
+	timeout := time.After(5 * time.Second)
+	for {
+		changed := game.lockingChanged()
+		if game.lockingInfo().State == Finished {
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("game %v didn't finish", game.Name)
+		}
+	}
+}
+
+func TestBots_SelfPlay(t *testing.T) {
+	for _, strategy := range []string{"random", "heuristic"} {
+		serverState := &NewServer().state
+		seed := int64(1)
+		response := StartGame(serverState, &StartGameRequest{
+			NumPlayers: 3,
+			Name:       "test_game",
+			Seed:       &seed,
+			Bots:       []string{strategy, strategy, strategy},
+		}).(*StartGameResponse)
+		require.Equal(t, "ok", response.Status, "%v", response.Reason)
+		game := serverState.Games["test_game"]
+		waitForFinish(t, game)
+
+		info := game.lockingInfo()
+		require.Equal(t, []string{botName(strategy, 0), botName(strategy, 1), botName(strategy, 2)}, info.Players)
+		t.Logf("%v bots scored %v", strategy, info.Score)
+	}
+}
+
+func TestBots_HeuristicScores(t *testing.T) {
+	total := 0
+	for seed := int64(0); seed < 20; seed++ {
+		serverState := &NewServer().state
+		seed := seed
+		StartGame(serverState, &StartGameRequest{
+			NumPlayers: 2,
+			Name:       "test_game",
+			Seed:       &seed,
+			Bots:       []string{"heuristic", "heuristic"},
+		})
+		game := serverState.Games["test_game"]
+		waitForFinish(t, game)
+		total += game.lockingInfo().Score
+	}
+	// It averages just over 8 on these seeds, and random bots about 1.
+	require.Greater(t, float64(total)/20, 7.5, "the heuristic bot got worse")
+}
+
+func TestBots_WithPlayer(t *testing.T) {
+	serverState := &NewServer().state
+	StartGame(serverState, &StartGameRequest{
+		NumPlayers: 2,
+		Name:       "test_game",
+		Bots:       []string{"heuristic"},
+	})
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player"}).(*JoinGameResponse)
+	require.Equal(t, "ok", r.Status, "%v", r.Reason)
+
+	// The bot goes first, then it's our turn.
+	response := GetState(serverState, &GetStateRequest{Session: r.Session, Wait: true}).(*GetStateResponse)
+	require.Equal(t, YourTurn, response.State.State)
+	require.Len(t, response.State.Turns, 1)
+	require.Equal(t, botName("heuristic", 0), response.State.Turns[0].Player)
+}
+
+func TestBots_BadParams(t *testing.T) {
+	serverState := &NewServer().state
+	response := StartGame(serverState, &StartGameRequest{
+		NumPlayers: 2,
+		Name:       "test_game",
+		Bots:       []string{"nonsense"},
+	}).(*StartGameResponse)
+	require.Equal(t, "error", response.Status)
+
+	response = StartGame(serverState, &StartGameRequest{
+		NumPlayers: 2,
+		Name:       "test_game",
+		Bots:       []string{"random", "random", "random"},
+	}).(*StartGameResponse)
+	require.Equal(t, "error", response.Status)
+	require.Empty(t, serverState.Games)
+}
+
+// The standard game, except 5s can't be hinted.
+type noFiveHintsRules struct {
+	standardRules
+}
+
+func (noFiveHintsRules) ID() Variant {
+	return "test-no-five-hints"
+}
+
+func (noFiveHintsRules) CanHintNumber(number int) bool {
+	return number != 5
+}
+
+func TestBots_HintsFollowRules(t *testing.T) {
+	registerRuleSet(noFiveHintsRules{})
+	state := &GameStateSummary{
+		Variant:    "test-no-five-hints",
+		Players:    []string{"a", "b"},
+		OtherHands: map[string][]Card{"b": {{ID: 0, Color: Red, Number: 5}}},
+	}
+	hints := legalHints(state)
+	require.Len(t, hints, 1, "only the color hint")
+	require.NotNil(t, hints[0].Color)
+}
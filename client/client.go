@@ -0,0 +1,134 @@
+// Package client talks to a hanabi-server, and defines the types it speaks.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Client struct {
+	URL  string // where the server is, like "http://localhost:9001"
+	HTTP *http.Client
+}
+
+func New(url string) *Client {
+	return &Client{
+		URL:  strings.TrimSuffix(url, "/"),
+		HTTP: http.DefaultClient,
+	}
+}
+
+func (c *Client) StartGame(req *StartGameRequest) error {
+	var res StartGameResponse
+	err := c.post("start-game", req, &res)
+	if err != nil {
+		return err
+	}
+	return checkStatus(res.Status, res.Reason)
+}
+
+func (c *Client) JoinGame(gameName string, playerName string) (SessionToken, error) {
+	return c.Join(&JoinGameRequest{GameName: gameName, PlayerName: playerName})
+}
+
+// Join with a secret, or use it to get the seat back after losing the session.
+func (c *Client) Join(req *JoinGameRequest) (SessionToken, error) {
+	var res JoinGameResponse
+	err := c.post("join-game", req, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Session, checkStatus(res.Status, res.Reason)
+}
+
+// Join the next game that needs a player with these settings.
+// The game starts once it fills up, so wait for the first turn with WaitForTurn.
+func (c *Client) Enqueue(req *EnqueueRequest) (session SessionToken, gameName string, err error) {
+	var res EnqueueResponse
+	err = c.post("enqueue", req, &res)
+	if err != nil {
+		return "", "", err
+	}
+	return res.Session, res.GameName, checkStatus(res.Status, res.Reason)
+}
+
+// Give up the seat. Mid-game that ends the game, unless a substitute bot takes over.
+func (c *Client) LeaveGame(session SessionToken) error {
+	var res LeaveGameResponse
+	err := c.post("leave-game", &LeaveGameRequest{Session: session}, &res)
+	if err != nil {
+		return err
+	}
+	return checkStatus(res.Status, res.Reason)
+}
+
+// A read-only session. asPlayer is optional, see SpectateRequest.
+func (c *Client) Spectate(gameName string, asPlayer string) (SessionToken, error) {
+	var res SpectateResponse
+	err := c.post("spectate", &SpectateRequest{GameName: gameName, AsPlayer: asPlayer}, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Session, checkStatus(res.Status, res.Reason)
+}
+
+func (c *Client) GetState(req *GetStateRequest) (*GameStateSummary, error) {
+	var res GetStateResponse
+	err := c.post("get-state", req, &res)
+	if err != nil {
+		return nil, err
+	}
+	err = checkStatus(res.Status, res.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return &res.State, nil
+}
+
+// Long-poll until it's this player's turn or the game is over.
+func (c *Client) WaitForTurn(session SessionToken) (*GameStateSummary, error) {
+	return c.GetState(&GetStateRequest{Session: session, Wait: true})
+}
+
+// Long-poll until there's a turn after turnCursor, it's this player's turn, or
+// the game is over. Only the turns after turnCursor are returned.
+func (c *Client) WaitForTurnAfter(session SessionToken, turnCursor int) (*GameStateSummary, error) {
+	return c.GetState(&GetStateRequest{Session: session, Wait: true, TurnCursor: &turnCursor})
+}
+
+func (c *Client) Move(session SessionToken, move Move) error {
+	var res MoveResponse
+	err := c.post("move", &MoveRequest{Session: session, Move: move}, &res)
+	if err != nil {
+		return err
+	}
+	return checkStatus(res.Status, res.Reason)
+}
+
+func (c *Client) post(endpoint string, req interface{}, res interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("error encoding request: %v", err)
+	}
+	httpRes, err := c.HTTP.Post(c.URL+"/hanabi/"+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	// Errors come back as JSON with a reason too, whatever the status code.
+	err = json.NewDecoder(httpRes.Body).Decode(res)
+	if err != nil {
+		return fmt.Errorf("error decoding %v response (HTTP %v): %v", endpoint, httpRes.StatusCode, err)
+	}
+	return nil
+}
+
+func checkStatus(status string, reason string) error {
+	if status != "ok" {
+		return fmt.Errorf("server error: %v", reason)
+	}
+	return nil
+}
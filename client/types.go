@@ -0,0 +1,319 @@
+package client
+
+import (
+	"encoding/json"
+)
+
+// Types shared by the server and its clients.
+
+type MoveType string
+
+const (
+	Hint    MoveType = "hint"
+	Play    MoveType = "play"
+	Discard MoveType = "discard"
+)
+
+type Color string
+
+const (
+	Red    Color = "red"
+	Yellow Color = "yellow"
+	Green  Color = "green"
+	Blue   Color = "blue"
+	Black  Color = "black"
+	White  Color = "white"
+)
+
+type GameState string
+
+const (
+	NotStarted     GameState = "not-started"
+	WaitingForTurn GameState = "waiting-for-turn"
+	YourTurn       GameState = "your-turn"
+	Finished       GameState = "finished"
+	Abandoned      GameState = "abandoned"   // ended early, like when a player ran out of time
+	InProgress     GameState = "in-progress" // only when listing games, players see whose turn it is
+)
+
+// Whether the game has ended, one way or another.
+func (s GameState) Over() bool {
+	return s == Finished || s == Abandoned
+}
+
+// What happens when a player runs out of time for their turn.
+type TimeoutAction string
+
+const (
+	TimeoutDiscard TimeoutAction = "discard" // discard their oldest card
+	TimeoutAbandon TimeoutAction = "abandon" // end the game
+)
+
+type Variant string
+
+const (
+	Standard Variant = "standard"
+	Rainbow  Variant = "rainbow" // adds Black, which every color hint touches
+)
+
+type Move struct {
+	Type MoveType `json:"type"`
+	// for Hint:
+	ToPlayer *string `json:"to_player,omitempty"`
+	Color    *Color  `json:"color,omitempty"`
+	Number   *int    `json:"number,omitempty"`
+	CardIDs  []int   `json:"card_ids,omitempty"`
+	// for Play/Discard:
+	CardID *int `json:"card_id,omitempty"`
+}
+
+type Card struct {
+	ID     int   `json:"id"`
+	Color  Color `json:"color"`
+	Number int   `json:"number"`
+}
+
+// Card implements Cardy
+var _ Cardy = (*Card)(nil)
+
+func (f *Card) GetID() int {
+	return f.ID
+}
+
+func (f *Card) Hide() HiddenCard {
+	return HiddenCard{
+		ID: f.ID,
+	}
+}
+
+type HiddenCard struct {
+	ID int `json:"id"`
+}
+
+// HiddenCard implements Cardy
+var _ Cardy = (*HiddenCard)(nil)
+
+func (h *HiddenCard) GetID() int {
+	return h.ID
+}
+
+type Cardy interface {
+	GetID() int
+}
+type TurnResult string
+
+const (
+	Success   TurnResult = "success"
+	Misplay   TurnResult = "misplay" // costs a bomb
+	Discarded TurnResult = "discard"
+)
+
+type Turn struct {
+	ID     int    `json:"id"`     // turn number starting at 0
+	Player string `json:"player"` // player that made the move
+	Move   Move   `json:"move"`
+	// no NewCard for Hint move
+	NewCard Cardy `json:"new_card"`
+	// for Play/Discard: the card that left the hand and where it went
+	Card   *Card      `json:"card,omitempty"`
+	Result TurnResult `json:"result,omitempty"`
+	// tokens left after the turn
+	Hints     int `json:"hints"`
+	BombsLeft int `json:"bombs_left"`
+	// The player ran out of time and the server moved for them.
+	// Move is empty if the game was abandoned instead.
+	Timeout bool `json:"timeout,omitempty"`
+}
+
+type GameStateSummary struct {
+	State      GameState         `json:"state"`
+	Players    []string          `json:"players"`
+	Hand       []HiddenCard      `json:"hand"`        // the focused player's hand
+	OtherHands map[string][]Card `json:"other_hands"` // the other player's hands
+	Board      map[Color][]Card  `json:"board"`
+	Discard    []Card            `json:"discard"`
+	Turns      []Turn            `json:"turns"`
+	TurnCursor int               `json:"turn_cursor"`
+	Hints      int               `json:"hints"`      // hint tokens available
+	BombsLeft  int               `json:"bombs_left"` // strikes left before the game is lost
+	DeckSize   int               `json:"deck_size"`
+	TurnsLeft  int               `json:"turns_left"` // 0 until the last card is drawn
+	Score      int               `json:"score"`
+	WhoseTurn  string            `json:"whose_turn"` // empty unless the game is in progress
+	Seed       *int64            `json:"seed,omitempty"`
+	Variant    Variant           `json:"variant"`
+	// Seconds until the current turn times out. Missing if there's no time limit.
+	TurnTimeLeft *float64 `json:"turn_time_left,omitempty"`
+}
+
+// 64-bit hex
+type SessionToken string
+
+func (t *Turn) UnmarshalJSON(data []byte) error {
+	// Decode everything but NewCard as usual.
+	type plainTurn Turn
+	raw := struct {
+		*plainTurn
+		NewCard json.RawMessage `json:"new_card"`
+	}{plainTurn: (*plainTurn)(t)}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	t.NewCard = nil
+	if len(raw.NewCard) == 0 || string(raw.NewCard) == "null" {
+		return nil
+	}
+	var card Card
+	err = json.Unmarshal(raw.NewCard, &card)
+	if err != nil {
+		return err
+	}
+	if card.Color == "" {
+		// The player who drew it only sees the ID.
+		t.NewCard = &HiddenCard{ID: card.ID}
+	} else {
+		t.NewCard = &card
+	}
+	return nil
+}
+
+// A finished game in the hanab.live JSON replay format, which community viewers
+// and analysis tools can load.
+// The server's clue rules aren't hanab.live's: a discard never gives a clue back, and discarding with every
+// clue left is allowed. So for a game with a discard in it, hanab.live shows a different clue count, and may
+// reject moves that were legal here.
+type Replay struct {
+	Players []string       `json:"players"`
+	Deck    []ReplayCard   `json:"deck"` // in the order it was dealt, first hand first
+	Actions []ReplayAction `json:"actions"`
+	Options ReplayOptions  `json:"options"`
+	Seed    string         `json:"seed,omitempty"`
+}
+
+type ReplayCard struct {
+	SuitIndex int `json:"suitIndex"` // into the variant's colors
+	Rank      int `json:"rank"`
+}
+
+type ReplayAction struct {
+	Type   int `json:"type"`
+	Target int `json:"target"` // a card's index into the deck, or a player's seat
+	Value  int `json:"value"`  // the suit or rank of a clue, or why the game ended
+}
+
+type ReplayOptions struct {
+	Variant string `json:"variant"`
+}
+
+// Requests and responses.
+
+type StartGameRequest struct {
+	NumPlayers int    `json:"num_players"`
+	Name       string `json:"name"`
+	// Optional. The same seed always deals the same deck. Random if missing.
+	Seed *int64 `json:"seed,omitempty"`
+	// Optional. Deal in exactly this order instead: a permutation of the card indexes.
+	Order []int `json:"order,omitempty"`
+	// Optional. Or deal exactly this deck, the first hand first. Only colors and numbers matter.
+	Deck []Card `json:"deck,omitempty"`
+	// Optional. Or deal the deck from a hanab.live replay, in its variant and, if
+	// num_players is missing, with as many players.
+	Replay *Replay `json:"replay,omitempty"`
+	// Optional, with replay. Play the replay's first this many actions as soon as everyone has joined.
+	FastForward int `json:"fast_forward,omitempty"`
+	// Optional. The variant to play: "standard" (the default) or "rainbow".
+	Variant Variant `json:"variant,omitempty"`
+	// Optional. Built-in bots ("random" or "heuristic") that take the first seats.
+	Bots []string `json:"bots,omitempty"`
+	// Optional. Seconds each player has for a turn, unlimited if missing.
+	TurnTimeLimit float64 `json:"turn_time_limit,omitempty"`
+	// Optional. What to do when the time is up: "discard" (the default) or "abandon".
+	TimeoutAction TimeoutAction `json:"timeout_action,omitempty"`
+	// Optional. A built-in bot that takes over from anyone who leaves mid-game.
+	// Without one, the game is abandoned.
+	Substitute string `json:"substitute,omitempty"`
+}
+
+type StartGameResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type JoinGameRequest struct {
+	GameName   string `json:"game_name"`
+	PlayerName string `json:"player_name"`
+	// Optional. Joining again with the same name and secret gets the seat back.
+	Secret string `json:"secret,omitempty"`
+	// Optional. When getting the seat back, replace the session with a new one.
+	RotateSession bool `json:"rotate_session,omitempty"`
+}
+
+type JoinGameResponse struct {
+	Status  string       `json:"status"`
+	Reason  string       `json:"reason,omitempty"`
+	Session SessionToken `json:"session,omitempty"`
+}
+
+// Join the next game that needs a player, or start one.
+type EnqueueRequest struct {
+	PlayerName string  `json:"player_name"`
+	NumPlayers int     `json:"num_players"`
+	Variant    Variant `json:"variant,omitempty"` // defaults to standard
+	Secret     string  `json:"secret,omitempty"`  // to rejoin with join-game, like JoinGameRequest.Secret
+}
+
+type EnqueueResponse struct {
+	Status   string       `json:"status"`
+	Reason   string       `json:"reason,omitempty"`
+	Session  SessionToken `json:"session,omitempty"`
+	GameName string       `json:"game_name,omitempty"`
+}
+
+type LeaveGameRequest struct {
+	Session SessionToken `json:"session"`
+}
+
+type LeaveGameResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Watch a game without a seat. The session works with get-state and ws, but not move.
+type SpectateRequest struct {
+	GameName string `json:"game_name"`
+	// Optional. See the game as this player does. Every hand is shown if missing.
+	AsPlayer string `json:"as_player,omitempty"`
+	// Needed to see every hand of a game that isn't over.
+	AdminToken string `json:"admin_token,omitempty"`
+}
+
+type SpectateResponse struct {
+	Status  string       `json:"status"`
+	Reason  string       `json:"reason,omitempty"`
+	Session SessionToken `json:"session,omitempty"`
+}
+
+type GetStateRequest struct {
+	Session SessionToken `json:"session"`
+	Wait    bool         `json:"wait"`
+	// Only return turns after this one. Pass the turn_cursor from the last response.
+	TurnCursor *int `json:"turn_cursor,omitempty"`
+}
+
+type GetStateResponse struct {
+	Status string           `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+	State  GameStateSummary `json:"state,omitempty"`
+}
+
+type MoveRequest struct {
+	Session SessionToken `json:"session"`
+	Move    Move         `json:"move"`
+}
+
+type MoveResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurn_JSON(t *testing.T) {
+	cardID := 3
+	turns := []Turn{
+		{ID: 0, Player: "p1", Move: Move{Type: Play, CardID: &cardID}, NewCard: &Card{ID: 10, Color: Red, Number: 2}},
+		{ID: 1, Player: "p2", Move: Move{Type: Discard, CardID: &cardID}, NewCard: &HiddenCard{ID: 11}},
+		{ID: 2, Player: "p1", Move: Move{Type: Discard, CardID: &cardID}},
+	}
+	bs, err := json.Marshal(turns)
+	require.NoError(t, err)
+
+	var decoded []Turn
+	require.NoError(t, json.Unmarshal(bs, &decoded))
+	require.Equal(t, turns, decoded)
+}
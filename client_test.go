@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seveneightn9ne/hanabi-server/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Game(t *testing.T) {
+	ts := httptest.NewServer(NewServer().Routes())
+	defer ts.Close()
+	c := client.New(ts.URL)
+
+	require.NoError(t, c.StartGame(&client.StartGameRequest{NumPlayers: 2, Name: "test-game", Seed: &testSeed}))
+	require.Error(t, c.StartGame(&client.StartGameRequest{NumPlayers: 2, Name: "test-game"}), "duplicate game")
+
+	session1, err := c.JoinGame("test-game", "player1")
+	require.NoError(t, err)
+	session2, err := c.JoinGame("test-game", "player2")
+	require.NoError(t, err)
+	_, err = c.JoinGame("test-game", "player3")
+	require.Error(t, err, "game is full")
+
+	state, err := c.WaitForTurn(session1)
+	require.NoError(t, err)
+	require.Equal(t, client.YourTurn, state.State)
+
+	done := make(chan error)
+	go func() {
+		var err error
+		state, err = c.WaitForTurnAfter(session2, 0)
+		done <- err
+	}()
+	one := 1
+	require.NoError(t, c.Move(session1, client.Move{Type: client.Play, CardID: &one}))
+	require.NoError(t, <-done)
+	require.Len(t, state.Turns, 1)
+	require.Equal(t, "player1", state.Turns[0].Player)
+	require.Equal(t, 1, state.TurnCursor)
+
+	// player1 sees what player2 drew but not what they drew themselves.
+	eight := 8
+	require.NoError(t, c.Move(session2, client.Move{Type: client.Discard, CardID: &eight}))
+	state, err = c.GetState(&client.GetStateRequest{Session: session1})
+	require.NoError(t, err)
+	require.IsType(t, &client.HiddenCard{}, state.Turns[0].NewCard)
+	require.IsType(t, &client.Card{}, state.Turns[1].NewCard)
+
+	require.Error(t, c.Move(session2, client.Move{Type: client.Discard, CardID: &eight}), "not your turn")
+}
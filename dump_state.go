@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/subtle"
+	"time"
+)
+
+type DumpStateRequest struct {
+	AdminToken string `json:"admin_token"`
+	GameName   string `json:"game_name"`
+}
+
+// Everything the server knows about a game, hidden cards and all.
+type GameDump struct {
+	Name       string            `json:"name"`
+	NumPlayers int               `json:"num_players"`
+	Variant    Variant           `json:"variant"`
+	Seed       *int64            `json:"seed,omitempty"`
+	Created    time.Time         `json:"created"`
+	State      GameState         `json:"state"`
+	Players    []string          `json:"players"` // in turn order
+	Hands      map[string][]Card `json:"hands"`
+	Deck       []Card            `json:"deck"`            // the next card drawn is first
+	Dealt      []Card            `json:"dealt,omitempty"` // the whole deck, first hand first, once the game is full
+	CardsByID  map[int]Card      `json:"cards_by_id"`
+	Board      map[Color][]Card  `json:"board"`
+	Discard    []Card            `json:"discard"`
+	Hints      int               `json:"hints"`
+	BombsLeft  int               `json:"bombs_left"`
+	TurnsLeft  int               `json:"turns_left"`
+	WhoseTurn  int               `json:"whose_turn"` // index into players, -1 when the game is over
+	Score      int               `json:"score"`
+	Turns      []Turn            `json:"turns"`
+}
+
+type DumpStateResponse struct {
+	Status string    `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+	Game   *GameDump `json:"game,omitempty"`
+}
+
+func NewDumpStateResponseError(reason string) *DumpStateResponse {
+	return &DumpStateResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func DumpState(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*DumpStateRequest)
+	if !ok {
+		return NewDumpStateResponseError("cannot interpret the request as a DumpStateRequest")
+	}
+	if !state.checkAdminToken(req.AdminToken) {
+		return NewDumpStateResponseError("not authorized")
+	}
+	if req.GameName == "" {
+		return NewDumpStateResponseError("missing required field \"game_name\"")
+	}
+	game := state.lookupGame(req.GameName)
+	if game == nil {
+		return NewDumpStateResponseError("no game found with that name")
+	}
+	return &DumpStateResponse{
+		Status: "ok",
+		Game:   game.lockingDump(),
+	}
+}
+
+// Admin endpoints are disabled unless the server has an admin token.
+func (s *ServerState) checkAdminToken(token string) bool {
+	if s.AdminToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1
+}
+
+func (g *Game) lockingDump() *GameDump {
+	g.Lock()
+	defer g.Unlock()
+	return g.dump(&g.table)
+}
+
+// The game as it is with table t, which needn't be the game's own.
+// Requires game is locked!
+func (g *Game) dump(t *table) *GameDump {
+	dump := &GameDump{
+		Name:       g.Name,
+		NumPlayers: g.NumPlayers,
+		Variant:    t.rules.ID(),
+		Seed:       g.Seed,
+		Created:    g.Created,
+		State:      t.overallState(),
+		Players:    []string{},
+		Hands:      make(map[string][]Card),
+		Deck:       append([]Card{}, t.deck...),
+		Dealt:      append([]Card(nil), t.dealt...),
+		CardsByID:  make(map[int]Card, len(g.cardsByID)),
+		Board:      make(map[Color][]Card),
+		Discard:    append([]Card{}, t.discard...),
+		Hints:      t.hints,
+		BombsLeft:  t.bombs,
+		TurnsLeft:  t.turnsLeft,
+		WhoseTurn:  t.whoseTurn,
+		Score:      t.Score(),
+		Turns:      append([]Turn{}, t.turns...),
+	}
+	for seat, name := range t.names {
+		dump.Players = append(dump.Players, name)
+		dump.Hands[name] = append([]Card{}, t.hands[seat]...)
+	}
+	for id, card := range g.cardsByID {
+		dump.CardsByID[id] = card
+	}
+	for color, pile := range t.board {
+		dump.Board[color] = append([]Card{}, pile...)
+	}
+	return dump
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestDumpState_Basic(t *testing.T) {
+	serverState, game, session := serverGamePlayer()
+	serverState.AdminToken = "secret"
+	JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	one := 1
+	MoveHandler(serverState, &MoveRequest{Session: session, Move: Move{Type: Discard, CardID: &one}})
+
+	request := DumpStateRequest{AdminToken: "secret", GameName: "test_game"}
+	response := DumpState(serverState, &request).(*DumpStateResponse)
+	if response.Status == "error" {
+		t.Fatalf("Expected status ok but was error: %v", response.Reason)
+	}
+	dump := response.Game
+	if h := dump.Hands["player1"]; len(h) != 5 || h[0].Color == "" {
+		t.Errorf("Expected to see player1's whole hand but got %v", h)
+	}
+	if l := len(dump.Deck); l != 39 {
+		t.Errorf("Expected 39 cards in the deck but got %v", l)
+	}
+	if dump.Deck[0] != game.deck[0] {
+		t.Errorf("Expected the deck in draw order")
+	}
+	if l := len(dump.CardsByID); l != 50 {
+		t.Errorf("Expected all 50 cards by ID but got %v", l)
+	}
+	if l := len(dump.Turns); l != 1 {
+		t.Fatalf("Expected 1 turn but got %v", l)
+	}
+	if _, ok := dump.Turns[0].NewCard.(*Card); !ok {
+		t.Errorf("Expected the turn log to show the drawn card")
+	}
+}
+
+func TestDumpState_AdminToken(t *testing.T) {
+	serverState, _, _ := serverGamePlayer()
+	request := DumpStateRequest{AdminToken: "", GameName: "test_game"}
+	response := DumpState(serverState, &request).(*DumpStateResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected dump-state to be disabled without an admin token")
+	}
+
+	serverState.AdminToken = "secret"
+	request.AdminToken = "wrong"
+	response = DumpState(serverState, &request).(*DumpStateResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for the wrong admin token")
+	}
+	if response.Game != nil {
+		t.Errorf("Expected no game in an error response")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// Players who enqueue with the same settings are put in the same game.
+type matchSettings struct {
+	NumPlayers int     `json:"num_players"`
+	Variant    Variant `json:"variant"`
+}
+
+func NewEnqueueResponseError(reason string) *EnqueueResponse {
+	return &EnqueueResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func Enqueue(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*EnqueueRequest)
+	if !ok {
+		return NewEnqueueResponseError("cannot interpret the request as an EnqueueRequest")
+	}
+	if req.PlayerName == "" {
+		return NewEnqueueResponseError("missing required field \"player_name\"")
+	}
+	if req.NumPlayers == 0 {
+		return NewEnqueueResponseError("missing required field \"num_players\"")
+	}
+	if req.NumPlayers < 2 || req.NumPlayers > 5 {
+		return NewEnqueueResponseError("must specify 2-5 players")
+	}
+	settings := matchSettings{NumPlayers: req.NumPlayers, Variant: req.Variant}
+	if settings.Variant == "" {
+		settings.Variant = Standard
+	}
+	if lookupRuleSet(settings.Variant) == nil {
+		return NewEnqueueResponseError(fmt.Sprintf("unknown variant: %v", settings.Variant))
+	}
+
+	state.queueLock.Lock()
+	defer state.queueLock.Unlock()
+	game := state.queues[settings]
+	var session SessionToken
+	var err error
+	if game != nil && req.Secret != "" {
+		// Enqueueing again with the same secret gets back the seat that's waiting.
+		_, session, err = game.lockingRejoin(req.PlayerName, req.Secret, false)
+		if err == nil {
+			return &EnqueueResponse{
+				Status:   "ok",
+				Session:  session,
+				GameName: game.Name,
+			}
+		}
+		if err != errNotJoined {
+			return NewEnqueueResponseError(err.Error())
+		}
+	}
+	if game != nil {
+		session, err = game.lockingJoinGame(req.PlayerName, req.Secret)
+		if err != nil && game.lockingInfo().State == NotStarted {
+			// Someone by that name is already waiting. The game stays queued for everyone else.
+			return NewEnqueueResponseError(err.Error())
+		}
+		if err != nil {
+			// It filled up or ended some other way, so start another game.
+			game = nil
+		}
+	}
+	if game == nil {
+		game, err = state.createMatch(settings)
+		if err != nil {
+			return NewEnqueueResponseError(err.Error())
+		}
+		session, err = game.lockingJoinGame(req.PlayerName, req.Secret)
+		if err != nil {
+			return NewEnqueueResponseError(err.Error())
+		}
+	}
+	state.addSession(session, game)
+	if game.lockingInfo().State == NotStarted {
+		state.queues[settings] = game
+	} else {
+		delete(state.queues, settings)
+	}
+	log.Printf("Player enqueued %v -> %v", req.PlayerName, game.Name)
+	return &EnqueueResponse{
+		Status:   "ok",
+		Session:  session,
+		GameName: game.Name,
+	}
+}
+
+// Start a game for a queue, with a name no one else has.
+func (s *ServerState) createMatch(settings matchSettings) (*Game, error) {
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	for {
+		bs, err := RandBytes(4)
+		if err != nil {
+			return nil, fmt.Errorf("error generating game name")
+		}
+		name := "match-" + hex.EncodeToString(bs)
+		if _, ok := s.Games[name]; ok {
+			continue
+		}
+		game, err := s.createGame(&StartGameRequest{Name: name, NumPlayers: settings.NumPlayers, Variant: settings.Variant}, &settings)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Started game: %v (matchmaking)", name)
+		return game, nil
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func enqueue(t *testing.T, s *ServerState, req EnqueueRequest) *EnqueueResponse {
+	res := Enqueue(s, &req).(*EnqueueResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	return res
+}
+
+func stateOf(t *testing.T, s *ServerState, session SessionToken) GameStateSummary {
+	res := GetState(s, &GetStateRequest{Session: session}).(*GetStateResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	return res.State
+}
+
+func TestEnqueue_FillsGames(t *testing.T) {
+	s := &NewServer().state
+	a := enqueue(t, s, EnqueueRequest{PlayerName: "a", NumPlayers: 2})
+	state := stateOf(t, s, a.Session)
+	require.Equal(t, NotStarted, state.State)
+
+	// Different settings wait in a different game.
+	other := enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 2, Variant: Rainbow})
+	require.NotEqual(t, a.GameName, other.GameName)
+	other = enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 3})
+	require.NotEqual(t, a.GameName, other.GameName)
+
+	b := enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 2, Variant: Standard})
+	require.Equal(t, a.GameName, b.GameName)
+	require.Equal(t, YourTurn, stateOf(t, s, a.Session).State)
+	require.Equal(t, []string{"a", "b"}, stateOf(t, s, b.Session).Players)
+
+	// That game is full, so the next player starts a new one.
+	c := enqueue(t, s, EnqueueRequest{PlayerName: "c", NumPlayers: 2})
+	require.NotEqual(t, a.GameName, c.GameName)
+}
+
+func TestEnqueue_SameName(t *testing.T) {
+	s := &NewServer().state
+	a := enqueue(t, s, EnqueueRequest{PlayerName: "a", NumPlayers: 2, Secret: "shh"})
+	again := Enqueue(s, &EnqueueRequest{PlayerName: "a", NumPlayers: 2}).(*EnqueueResponse)
+	require.Equal(t, "error", again.Status, "a is already waiting")
+
+	// With the secret, a gets their seat back.
+	rejoined := enqueue(t, s, EnqueueRequest{PlayerName: "a", NumPlayers: 2, Secret: "shh"})
+	require.Equal(t, a.GameName, rejoined.GameName)
+	require.Equal(t, a.Session, rejoined.Session)
+	wrong := Enqueue(s, &EnqueueRequest{PlayerName: "a", NumPlayers: 2, Secret: "nope"}).(*EnqueueResponse)
+	require.Equal(t, "error", wrong.Status)
+
+	// The waiting game is still the one that fills.
+	b := enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 2})
+	require.Equal(t, a.GameName, b.GameName)
+	require.Equal(t, []string{"a", "b"}, stateOf(t, s, b.Session).Players)
+}
+
+func TestEnqueue_Errors(t *testing.T) {
+	s := &NewServer().state
+	for _, req := range []EnqueueRequest{
+		{NumPlayers: 2},
+		{PlayerName: "a"},
+		{PlayerName: "a", NumPlayers: 6},
+		{PlayerName: "a", NumPlayers: 2, Variant: "nope"},
+	} {
+		res := Enqueue(s, &req).(*EnqueueResponse)
+		require.Equal(t, "error", res.Status, "%+v", req)
+	}
+}
+
+func TestEnqueue_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	first := enqueue(t, &server.Server.state, EnqueueRequest{PlayerName: "a", NumPlayers: 2})
+
+	restored := newStoredServer(t, dir)
+	s := &restored.Server.state
+	second := enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 2})
+	require.Equal(t, first.GameName, second.GameName, "the waiting game is still queued")
+	require.Equal(t, YourTurn, stateOf(t, s, first.Session).State)
+
+	// Once it's full it isn't queued again.
+	again := newStoredServer(t, dir)
+	third := enqueue(t, &again.Server.state, EnqueueRequest{PlayerName: "c", NumPlayers: 2})
+	require.NotEqual(t, first.GameName, third.GameName)
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The state of a game is its deal with its events applied, in order.
+// Sessions, bots, clocks and storage are kept on the Game, outside the table.
+type eventType string
+
+const (
+	eventJoin    eventType = "join"    // a player takes the next seat and is dealt a hand
+	eventLeave   eventType = "leave"   // a player gives up their seat before the game starts
+	eventMove    eventType = "move"    // the player whose turn it is moves
+	eventTimeout eventType = "timeout" // the player whose turn it is ran out of time
+	eventAbandon eventType = "abandon" // the game ends early
+)
+
+// Events are saved as they happen, see Game.commit.
+type event struct {
+	Type   eventType     `json:"type"`
+	Player string        `json:"player,omitempty"` // who joined, left, moved or ran out of time
+	Move   *Move         `json:"move,omitempty"`   // for moves
+	Action TimeoutAction `json:"action,omitempty"` // for timeouts
+}
+
+// Everything about a game that events change.
+type table struct {
+	rules      RuleSet
+	numPlayers int
+
+	names     []string // by seat
+	hands     [][]Card // by seat
+	deck      Deck
+	dealt     []Card // the deck as it was dealt, seat by seat, once the game is full
+	board     map[Color][]Card
+	bombs     int
+	hints     int
+	discard   []Card
+	whoseTurn int // Seat. Use -1 when game is over
+	turnsLeft int // Turns until game end. 0 means unlimited (last card hasn't been drawn)
+	turns     []Turn
+	abandoned bool // ended early, whoseTurn is -1 too
+}
+
+func newTable(rules RuleSet, numPlayers int, deck Deck) table {
+	board := make(map[Color][]Card)
+	for _, color := range rules.Colors() {
+		board[color] = nil
+	}
+	return table{
+		rules:      rules,
+		numPlayers: numPlayers,
+		deck:       deck,
+		board:      board,
+		bombs:      rules.Bombs(),
+		hints:      rules.MaxHints(),
+		discard:    make([]Card, 0),
+		turns:      make([]Turn, 0),
+	}
+}
+
+// The table after e. Neither t nor anything it shares is changed, and on error
+// the result should be thrown away.
+func (t table) apply(e event) (table, error) {
+	t = t.clone()
+	var err error
+	switch e.Type {
+	case eventJoin:
+		err = t.join(e.Player)
+	case eventLeave:
+		err = t.leave(e.Player)
+	case eventMove:
+		if e.Move == nil {
+			err = fmt.Errorf("missing move")
+			break
+		}
+		err = t.move(e.Player, *e.Move)
+	case eventTimeout:
+		err = t.timeout(e.Player, e.Action)
+	case eventAbandon:
+		err = t.abandon(nil)
+	default:
+		err = fmt.Errorf("unknown event type: %v", e.Type)
+	}
+	return t, err
+}
+
+// The deal with events applied in order.
+func replayEvents(deal table, events []event) (table, error) {
+	t := deal
+	for i, e := range events {
+		var err error
+		t, err = t.apply(e)
+		if err != nil {
+			return t, fmt.Errorf("event %v (%v %v): %v", i, e.Type, e.Player, err)
+		}
+	}
+	return t, nil
+}
+
+// A copy that can be changed without changing t.
+func (t table) clone() table {
+	t.names = append([]string(nil), t.names...)
+	hands := make([][]Card, len(t.hands))
+	for i, hand := range t.hands {
+		hands[i] = append([]Card(nil), hand...)
+	}
+	t.hands = hands
+	t.deck = append(Deck(nil), t.deck...)
+	board := make(map[Color][]Card, len(t.board))
+	for color, pile := range t.board {
+		board[color] = append([]Card(nil), pile...)
+	}
+	t.board = board
+	t.discard = append([]Card{}, t.discard...)
+	t.turns = append([]Turn{}, t.turns...)
+	return t
+}
+
+// The state of the game as a whole, rather than from one player's point of view.
+func (t *table) overallState() GameState {
+	if t.abandoned {
+		// Games can be abandoned before they fill up, too.
+		return Abandoned
+	} else if len(t.names) < t.numPlayers {
+		return NotStarted
+	} else if t.whoseTurn == -1 {
+		return Finished
+	}
+	return InProgress
+}
+
+func (t *table) seatOf(name string) (int, error) {
+	for i, n := range t.names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("player not found: %v", name)
+}
+
+func (t *table) join(name string) error {
+	if len(t.names) >= t.numPlayers {
+		return fmt.Errorf("the game is full (%v/%v players)", len(t.names), t.numPlayers)
+	}
+	if t.abandoned {
+		return fmt.Errorf("the game is already over")
+	}
+	if _, err := t.seatOf(name); err == nil {
+		return fmt.Errorf("player with that name is already in the game")
+	}
+	c := t.rules.HandSize(t.numPlayers)
+	t.names = append(t.names, name)
+	t.hands = append(t.hands, append([]Card(nil), t.deck[:c]...))
+	t.deck = t.deck[c:]
+	if len(t.names) == t.numPlayers {
+		for _, hand := range t.hands {
+			t.dealt = append(t.dealt, hand...)
+		}
+		t.dealt = append(t.dealt, t.deck...)
+	}
+	return nil
+}
+
+func (t *table) leave(name string) error {
+	if t.overallState() != NotStarted {
+		return fmt.Errorf("the game has already started")
+	}
+	seat, err := t.seatOf(name)
+	if err != nil {
+		return err
+	}
+	// Back on top, so the next player to join is dealt the same cards.
+	t.deck = append(t.hands[seat], t.deck...)
+	t.names = append(t.names[:seat], t.names[seat+1:]...)
+	t.hands = append(t.hands[:seat], t.hands[seat+1:]...)
+	return nil
+}
+
+func (t *table) move(name string, move Move) error {
+	seat, err := t.seatOf(name)
+	if err != nil {
+		return err
+	}
+	if t.whoseTurn != seat {
+		return fmt.Errorf("not your turn it's player %v's turn", t.whoseTurn)
+	}
+	if t.overallState() != InProgress {
+		return fmt.Errorf("the game hasn't started yet")
+	}
+
+	switch move.Type {
+	case Play:
+		var result TurnResult
+		if move.CardID == nil {
+			return fmt.Errorf("missing required field card_id for move type PLAY")
+		}
+		card := t.takeFromHand(seat, *move.CardID)
+		if card == nil {
+			return fmt.Errorf("Card #%v is not in your hand", *move.CardID)
+		}
+
+		pile := t.board[card.Color]
+		var topCard int
+		if len(pile) == 0 {
+			topCard = 0
+		} else {
+			topCard = pile[len(pile)-1].Number
+		}
+		if topCard+1 == card.Number {
+			// Hooray, well done!
+			if numbers := t.rules.Numbers(); card.Number == numbers[len(numbers)-1] {
+				// Completed a pile, grant a hint
+				t.regainHint()
+			}
+			t.board[card.Color] = append(pile, *card)
+			result = Success
+		} else {
+			// Oof, wrong card
+			t.bombs -= 1
+
+			// Card goes in discard pile
+			t.discard = append(t.discard, *card)
+			result = Misplay
+		}
+		// You get a new card!
+		newCard := t.draw(seat)
+		t.endTurn(Turn{
+			ID:     len(t.turns),
+			Player: name,
+			Move: Move{
+				Type:   Play,
+				CardID: move.CardID,
+			},
+			NewCard: newCard,
+			Card:    card,
+			Result:  result,
+		})
+		return nil
+	case Discard:
+		if move.CardID == nil {
+			return fmt.Errorf("missing required field card_id for move type DISCARD")
+		}
+		return t.discardCard(seat, *move.CardID, false)
+	case Hint:
+		if t.hints < 1 {
+			return fmt.Errorf("no hint credits available")
+		}
+		if move.CardID != nil {
+			return fmt.Errorf("unexpected CardID in HINT move")
+		}
+		turn := Turn{
+			ID:     len(t.turns),
+			Player: name,
+			Move: Move{
+				Type:     Hint,
+				ToPlayer: move.ToPlayer,
+				Color:    move.Color,
+				Number:   move.Number,
+				CardIDs:  move.CardIDs,
+			},
+			NewCard: nil,
+		}
+
+		// Check that the hint is valid
+		if turn.Move.ToPlayer == nil {
+			return fmt.Errorf("hint missing required field 'to_player'")
+		}
+		err = t.checkHint(*turn.Move.ToPlayer, turn.Move.Color, turn.Move.Number, turn.Move.CardIDs)
+		if err != nil {
+			return err
+		}
+
+		// commit
+		t.hints--
+		t.endTurn(turn)
+		return nil
+	default:
+		return fmt.Errorf("unrecognized move type: %v", move.Type)
+	}
+}
+
+// timeout is set when the server discards for a player who ran out of time.
+func (t *table) discardCard(seat int, cardID int, timeout bool) error {
+	card := t.takeFromHand(seat, cardID)
+	if card == nil {
+		return fmt.Errorf("Card #%v is not in your hand", cardID)
+	}
+
+	t.discard = append(t.discard, *card)
+	// You get a new card!
+	newCard := t.draw(seat)
+	t.endTurn(Turn{
+		ID:     len(t.turns),
+		Player: t.names[seat],
+		Move: Move{
+			Type:   Discard,
+			CardID: &cardID,
+		},
+		NewCard: newCard,
+		Card:    card,
+		Result:  Discarded,
+		Timeout: timeout,
+	})
+	return nil
+}
+
+// The current player ran out of time.
+func (t *table) timeout(name string, action TimeoutAction) error {
+	if t.overallState() != InProgress || t.names[t.whoseTurn] != name {
+		return fmt.Errorf("%v can't time out, it isn't their turn", name)
+	}
+	hand := t.hands[t.whoseTurn]
+	if action == TimeoutAbandon || len(hand) == 0 {
+		return t.abandon(&Turn{ID: len(t.turns), Player: name, Timeout: true})
+	}
+	// Drawn cards go on the end of the hand, so the oldest card is first.
+	return t.discardCard(t.whoseTurn, hand[0].ID, true)
+}
+
+// End the game early. The turn that ended it, if any, is added to the turns.
+func (t *table) abandon(turn *Turn) error {
+	if t.overallState().Over() {
+		return fmt.Errorf("the game is already over")
+	}
+	if turn != nil {
+		turn.Hints = t.hints
+		turn.BombsLeft = t.bombs
+		t.turns = append(t.turns, *turn)
+	}
+	t.abandoned = true
+	t.whoseTurn = -1
+	t.turnsLeft = 0
+	return nil
+}
+
+// Draw a card into seat's hand, nil if the deck is empty.
+func (t *table) draw(seat int) *Card {
+	l := len(t.deck)
+	if l == 0 {
+		return nil
+	} else if l == 1 {
+		// Drawing the last card.
+		// Game will end in len(players) turns
+		t.turnsLeft = t.rules.FinalTurns(len(t.names)) + 1
+	}
+	// Draw from the top, the same end hands are dealt from.
+	c := t.deck[0]
+	t.deck = t.deck[1:]
+	t.hands[seat] = append(t.hands[seat], c)
+	return &c
+}
+
+// Removes the card from the hand!
+func (t *table) takeFromHand(seat int, cardID int) *Card {
+	hand := t.hands[seat]
+	for i, card := range hand {
+		if card.ID == cardID {
+			t.hands[seat] = append(hand[:i], hand[i+1:]...)
+			return &card
+		}
+	}
+	return nil
+}
+
+func (t *table) endTurn(turn Turn) {
+	turn.Hints = t.hints
+	turn.BombsLeft = t.bombs
+	t.turns = append(t.turns, turn)
+	if t.turnsLeft == 1 || t.rules.IsOver(t.Score(), t.bombs) {
+		// This is the last turn, game over.
+		t.whoseTurn = -1
+		t.turnsLeft = 0
+		return
+	}
+	if t.turnsLeft > 0 {
+		t.turnsLeft--
+	}
+	t.whoseTurn++
+	if t.whoseTurn >= len(t.names) {
+		t.whoseTurn = 0
+	}
+}
+
+// Get a hint back, unless they're all there already.
+func (t *table) regainHint() {
+	if t.hints < t.rules.MaxHints() {
+		t.hints++
+	}
+}
+
+func (t *table) Score() int {
+	score := 0
+	for _, pile := range t.board {
+		if len(pile) > 0 {
+			topCard := pile[len(pile)-1]
+			score += topCard.Number
+		}
+	}
+	return score
+}
+
+// Check that the hint and cardIDs line up with the truth.
+func (t *table) checkHint(toPlayer string, color *Color, number *int, cardIDs []int) error {
+	if color == nil && number == nil {
+		return fmt.Errorf("hint must have color or number but found neither")
+	}
+	if color != nil && number != nil {
+		return fmt.Errorf("hint must have color or number but found both")
+	}
+	if color != nil {
+		err := t.checkCardColor(*color)
+		if err != nil {
+			return err
+		}
+	}
+	if number != nil {
+		err := t.checkCardNumber(*number)
+		if err != nil {
+			return err
+		}
+	}
+
+	hinted, err := t.seatOf(toPlayer)
+	if err != nil {
+		return err
+	}
+
+	var cardIDs2 []int
+	for _, cardID := range cardIDs {
+		cardIDs2 = append(cardIDs2, cardID)
+	}
+	sort.Ints(cardIDs2)
+
+	var cardIDsRef []int
+	for _, card := range t.hands[hinted] {
+		if color != nil && t.rules.ColorTouches(*color, card) {
+			cardIDsRef = append(cardIDsRef, card.ID)
+		}
+		if number != nil && t.rules.NumberTouches(*number, card) {
+			cardIDsRef = append(cardIDsRef, card.ID)
+		}
+	}
+	sort.Ints(cardIDsRef)
+
+	if len(cardIDs2) != len(cardIDsRef) {
+		return fmt.Errorf("invalid hint: got %v expected %v (length)", cardIDs, cardIDsRef)
+	}
+	for i, v := range cardIDsRef {
+		if cardIDs2[i] != v {
+			return fmt.Errorf("invalid hint: got %v expected %v (at index %v)", cardIDs, cardIDsRef, i)
+		}
+	}
+	return nil
+}
+
+func (t *table) checkCardColor(color Color) error {
+	if !t.rules.CanHintColor(color) {
+		return fmt.Errorf("invalid color: %v", color)
+	}
+	return nil
+}
+
+func (t *table) checkCardNumber(number int) error {
+	if !t.rules.CanHintNumber(number) {
+		return fmt.Errorf("invalid number: %v", number)
+	}
+	return nil
+}
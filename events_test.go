@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// A standard deal, in the rule set's order, with everyone seated.
+func seatedTable(t *testing.T, players ...string) table {
+	rules := lookupRuleSet(Standard)
+	order := make([]int, len(rules.Deck()))
+	for i := range order {
+		order[i] = i
+	}
+	deck, _ := newDeck(rules, order)
+	tab := newTable(rules, len(players), deck)
+	for _, name := range players {
+		var err error
+		tab, err = tab.apply(event{Type: eventJoin, Player: name})
+		require.NoError(t, err)
+	}
+	return tab
+}
+
+func TestApply_LeavesTableAlone(t *testing.T) {
+	before := seatedTable(t, "alice", "bob")
+	saved := before.clone()
+	move := discardMove(before.hands[0][0].ID)
+	discard := event{Type: eventMove, Player: "alice", Move: &move}
+
+	after, err := before.apply(discard)
+	require.NoError(t, err)
+	require.Equal(t, saved, before)
+	require.Len(t, after.turns, 1)
+	require.Len(t, after.discard, 1)
+	require.Len(t, after.deck, len(before.deck)-1)
+
+	again, err := before.apply(discard)
+	require.NoError(t, err)
+	require.Equal(t, after, again, "the same event on the same table gives the same table")
+}
+
+func TestApply_Rejected(t *testing.T) {
+	before := seatedTable(t, "alice", "bob")
+	saved := before.clone()
+	move := discardMove(before.hands[1][0].ID)
+	_, err := before.apply(event{Type: eventMove, Player: "bob", Move: &move})
+	require.Error(t, err, "it's alice's turn")
+	_, err = before.apply(event{Type: eventJoin, Player: "carol"})
+	require.Error(t, err, "the game is full")
+	require.Equal(t, saved, before)
+}
+
+func TestApply_Leave(t *testing.T) {
+	tab := seatedTable(t, "alice")
+	tab.numPlayers = 2
+	deckSize := len(tab.deck)
+	hand := tab.hands[0]
+
+	tab, err := tab.apply(event{Type: eventLeave, Player: "alice"})
+	require.NoError(t, err)
+	require.Empty(t, tab.names)
+	require.Len(t, tab.deck, deckSize+len(hand))
+
+	tab, err = tab.apply(event{Type: eventJoin, Player: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, hand, tab.hands[0], "bob is dealt the hand alice gave back")
+}
+
+func TestTableAt_RebuildsEveryTurn(t *testing.T) {
+	s := &NewServer().state
+	seed := int64(5)
+	res := StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "bots", Seed: &seed, Bots: []string{"heuristic", "heuristic"}}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	game := s.lookupGame("bots")
+	waitForFinish(t, game)
+
+	game.Lock()
+	defer game.Unlock()
+	require.Len(t, game.events, 2+len(game.turns), "two joins, then one event per turn")
+	for n := 0; n <= len(game.events); n++ {
+		tab, err := game.tableAt(n)
+		require.NoError(t, err)
+		require.Equal(t, game.turns[:len(tab.turns)], tab.turns)
+	}
+	tab, err := game.tableAt(len(game.events))
+	require.NoError(t, err)
+	require.Equal(t, game.table, tab)
+
+	_, err = game.tableAt(len(game.events) + 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Action types.
+const (
+	replayPlay      = 0
+	replayDiscard   = 1
+	replayColorClue = 2
+	replayRankClue  = 3
+	replayGameOver  = 4
+)
+
+// Why a game ended early, for replayGameOver.
+const (
+	replayEndTimeout    = 3
+	replayEndTerminated = 4
+)
+
+var hanabLiveVariants = map[Variant]string{
+	Standard: "No Variant",
+	Rainbow:  "Rainbow (6 Suits)",
+}
+
+type ExportReplayRequest struct {
+	GameName string `json:"game_name"`
+}
+
+type ExportReplayResponse struct {
+	Status string  `json:"status"`
+	Reason string  `json:"reason,omitempty"`
+	Replay *Replay `json:"replay,omitempty"`
+}
+
+func NewExportReplayResponseError(reason string) *ExportReplayResponse {
+	return &ExportReplayResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func ExportReplay(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*ExportReplayRequest)
+	if !ok {
+		return NewExportReplayResponseError("cannot interpret the request as an ExportReplayRequest")
+	}
+	if req.GameName == "" {
+		return NewExportReplayResponseError("missing required field \"game_name\"")
+	}
+	game := state.lookupGame(req.GameName)
+	if game == nil {
+		return NewExportReplayResponseError("no game found with that name")
+	}
+	replay, err := exportReplay(game.lockingDump())
+	if err != nil {
+		return NewExportReplayResponseError(err.Error())
+	}
+	return &ExportReplayResponse{
+		Status: "ok",
+		Replay: replay,
+	}
+}
+
+// The replay reveals every card, so only games that are over can be exported.
+func exportReplay(dump *GameDump) (*Replay, error) {
+	if !dump.State.Over() {
+		return nil, fmt.Errorf("the game isn't over yet")
+	}
+	return replayOf(dump)
+}
+
+// The game so far as a replay, whether or not it's over.
+func replayOf(dump *GameDump) (*Replay, error) {
+	if len(dump.Dealt) == 0 {
+		return nil, fmt.Errorf("the game never started")
+	}
+	rules := lookupRuleSet(dump.Variant)
+	variantName, ok := hanabLiveVariants[dump.Variant]
+	if rules == nil || !ok {
+		return nil, fmt.Errorf("can't export variant %v", dump.Variant)
+	}
+	r := &Replay{
+		Players: dump.Players,
+		Deck:    []ReplayCard{},
+		Actions: []ReplayAction{},
+		Options: ReplayOptions{Variant: variantName},
+	}
+	if dump.Seed != nil {
+		r.Seed = strconv.FormatInt(*dump.Seed, 10)
+	}
+
+	suits := make(map[Color]int)
+	for i, color := range rules.Colors() {
+		suits[color] = i
+	}
+	deckIndex := make(map[int]int) // card ID -> index into r.Deck
+	for i, card := range dump.Dealt {
+		deckIndex[card.ID] = i
+		r.Deck = append(r.Deck, ReplayCard{SuitIndex: suits[card.Color], Rank: card.Number})
+	}
+	seats := make(map[string]int)
+	for i, player := range dump.Players {
+		seats[player] = i
+	}
+
+	for _, turn := range dump.Turns {
+		move := turn.Move
+		switch move.Type {
+		case Play:
+			r.Actions = append(r.Actions, ReplayAction{Type: replayPlay, Target: deckIndex[*move.CardID]})
+		case Discard:
+			// Discards the server made on a timeout look like any other.
+			r.Actions = append(r.Actions, ReplayAction{Type: replayDiscard, Target: deckIndex[*move.CardID]})
+		case Hint:
+			action := ReplayAction{Type: replayRankClue, Target: seats[*move.ToPlayer]}
+			if move.Color != nil {
+				action.Type = replayColorClue
+				action.Value = suits[*move.Color]
+			} else {
+				action.Value = *move.Number
+			}
+			r.Actions = append(r.Actions, action)
+		}
+	}
+	if dump.State == Abandoned {
+		over := ReplayAction{Type: replayGameOver, Value: replayEndTerminated}
+		if n := len(dump.Turns); n > 0 && dump.Turns[n-1].Timeout && dump.Turns[n-1].Move.Type == "" {
+			over.Target = seats[dump.Turns[n-1].Player]
+			over.Value = replayEndTimeout
+		}
+		r.Actions = append(r.Actions, over)
+	}
+	return r, nil
+}
+
+// $ hanabi-server export-replay -data-dir games -game thegame > thegame.json
+// Reads the saved games without changing them, so it's safe while the server runs.
+func exportReplayCommand(args []string) int {
+	flags := flag.NewFlagSet("export-replay", flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "the server's -data-dir")
+	gameName := flags.String("game", "", "name of the game to export")
+	flags.Parse(args)
+	if *dataDir == "" || *gameName == "" {
+		fmt.Fprintln(os.Stderr, "-data-dir and -game are required")
+		return 2
+	}
+
+	dump, err := findSavedGame(*dataDir, *gameName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	replay, err := exportReplay(dump)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(replay)
+	return 0
+}
+
+// Look for a game in the log, then among the reaped games in the archive.
+func findSavedGame(dir string, name string) (*GameDump, error) {
+	var entries []LogEntry
+	err := readLines(filepath.Join(dir, logFileName), func(line []byte) error {
+		var entry LogEntry
+		err := json.Unmarshal(line, &entry)
+		entries = append(entries, entry)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &NewServer().state
+	for i, entry := range entries {
+		err := s.replay(entry)
+		if err != nil {
+			return nil, fmt.Errorf("replaying entry %v (%v %v): %v", i, entry.Type, entry.Game, err)
+		}
+	}
+	if game := s.lookupGame(name); game != nil {
+		return game.lockingDump(), nil
+	}
+
+	var found *GameDump
+	err = readLines(filepath.Join(dir, archiveFileName), func(line []byte) error {
+		var dump GameDump
+		err := json.Unmarshal(line, &dump)
+		if err == nil && dump.Name == name {
+			found = &dump
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no game found with that name")
+	}
+	return found, nil
+}
+
+// Call f with each complete line of the file, if there is a file.
+// A partial last line, from a write in progress, is skipped.
+func readLines(path string, f func(line []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = f(line)
+		if err != nil {
+			return fmt.Errorf("reading %v: %v", path, err)
+		}
+	}
+}
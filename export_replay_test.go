@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func exportGame(s *ServerState, name string) *ExportReplayResponse {
+	return ExportReplay(s, &ExportReplayRequest{GameName: name}).(*ExportReplayResponse)
+}
+
+// A replay in hanab.live's export format, from testdata.
+func loadReplay(t *testing.T, name string) *Replay {
+	bs, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	var replay Replay
+	require.NoError(t, json.Unmarshal(bs, &replay))
+	return &replay
+}
+
+func TestExportReplay_Finished(t *testing.T) {
+	s := &NewServer().state
+	seed := int64(3)
+	res := StartGame(s, &StartGameRequest{NumPlayers: 3, Name: "bots", Seed: &seed, Variant: Rainbow, Bots: []string{"heuristic", "heuristic", "random"}}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	game := s.lookupGame("bots")
+	waitForFinish(t, game)
+
+	exported := exportGame(s, "bots")
+	require.Equal(t, "ok", exported.Status, "%v", exported.Reason)
+	replay := exported.Replay
+	dump := game.lockingDump()
+	require.Equal(t, "Rainbow (6 Suits)", replay.Options.Variant)
+	require.Equal(t, "3", replay.Seed)
+	require.Equal(t, dump.Players, replay.Players)
+	require.Len(t, replay.Deck, 60)
+	require.Len(t, replay.Actions, len(dump.Turns))
+
+	colors := RainbowColors[:]
+	for i, turn := range dump.Turns {
+		action := replay.Actions[i]
+		switch turn.Move.Type {
+		case Play, Discard:
+			// The action points at the card that was played.
+			card := replay.Deck[action.Target]
+			require.Equal(t, turn.Card.Color, colors[card.SuitIndex])
+			require.Equal(t, turn.Card.Number, card.Rank)
+		case Hint:
+			require.Equal(t, *turn.Move.ToPlayer, replay.Players[action.Target])
+		}
+	}
+}
+
+func TestExportReplay_DealOrder(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	s := &server.Server.state
+	// The first player leaves, so the next one is dealt the first cards.
+	first := server.newTestPlayer()
+	require.Equal(t, "ok", leave(t, s, first.Session).Status)
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+	game := s.lookupGame("test-game")
+	hands := [][]Card{game.handOf(players[0].Session), game.handOf(players[1].Session)}
+	require.Equal(t, "ok", leave(t, s, players[0].Session).Status)
+
+	exported := exportGame(s, "test-game")
+	require.Equal(t, "ok", exported.Status, "%v", exported.Reason)
+	replay := exported.Replay
+	require.Equal(t, []string{"test-player-1", "test-player-2"}, replay.Players)
+	// hanab.live deals a whole hand to each player in turn from the top of the deck.
+	for i, card := range append(hands[0], hands[1]...) {
+		require.Equal(t, card.Color, Colors[replay.Deck[i].SuitIndex])
+		require.Equal(t, card.Number, replay.Deck[i].Rank)
+	}
+	require.Equal(t, []ReplayAction{{Type: replayGameOver, Value: replayEndTerminated}}, replay.Actions)
+}
+
+func TestExportReplay_Timeout(t *testing.T) {
+	s := &NewServer().state
+	sessions := startTimedGame(t, s, 0.01, TimeoutAbandon)
+	_, err := getStateLoop(s.lookupGame("test-game"), sessions[1], true, nil)
+	require.NoError(t, err)
+
+	exported := exportGame(s, "test-game")
+	require.Equal(t, "ok", exported.Status, "%v", exported.Reason)
+	require.Equal(t, []ReplayAction{{Type: replayGameOver, Target: 0, Value: replayEndTimeout}}, exported.Replay.Actions)
+}
+
+func TestExportReplay_NotOver(t *testing.T) {
+	server, _ := setupTest(t, 2)
+	res := exportGame(&server.Server.state, "test-game")
+	require.Equal(t, "error", res.Status)
+	require.Equal(t, "error", exportGame(&server.Server.state, "nope").Status)
+}
+
+func TestExportReplay_Saved(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	s := &server.Server.state
+	sessions := startTimedGame(t, s, 0.01, TimeoutAbandon)
+	_, err := getStateLoop(s.lookupGame("test-game"), sessions[1], true, nil)
+	require.NoError(t, err)
+	live := exportGame(s, "test-game").Replay
+
+	dump, err := findSavedGame(dir, "test-game")
+	require.NoError(t, err)
+	saved, err := exportReplay(dump)
+	require.NoError(t, err)
+	require.Equal(t, live, saved)
+
+	// Still there after it's reaped and archived.
+	require.Len(t, s.reap(time.Now().Add(time.Hour), ReapConfig{Finished: time.Minute}), 1)
+	dump, err = findSavedGame(dir, "test-game")
+	require.NoError(t, err)
+	archived, err := exportReplay(dump)
+	require.NoError(t, err)
+	require.Equal(t, live, archived)
+
+	_, err = findSavedGame(dir, "nope")
+	require.Error(t, err)
+}
+
+func TestExportReplay_HanabLive(t *testing.T) {
+	// Eight clues, then a discard. hanab.live gives a clue back for it, then spends it.
+	live := loadReplay(t, "hanab-live-discard-then-clue.json")
+	s := &NewServer().state
+	game := startAndFill(t, s, StartGameRequest{Name: "live", Replay: live, FastForward: 9})
+	require.Equal(t, 0, game.lockingDump().Hints, "this server doesn't give a clue back for discarding")
+	require.NoError(t, game.lockingLeave(game.players[0]))
+
+	exported := exportGame(s, "live")
+	require.Equal(t, "ok", exported.Status, "%v", exported.Reason)
+	require.Equal(t, live.Deck, exported.Replay.Deck)
+	require.Equal(t, live.Options, exported.Replay.Options)
+	end := live.Actions[len(live.Actions)-1]
+	require.Equal(t, append(live.Actions[:9:9], end), exported.Replay.Actions)
+}
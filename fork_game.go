@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+type ForkGameRequest struct {
+	GameName string `json:"game_name"`
+	// The new game starts just before this turn of the old one, so 0 is the deal.
+	Turn int `json:"turn"`
+	// Optional. Defaults to "<game_name>-fork-<random>".
+	NewName string `json:"new_name,omitempty"`
+	// Needed to fork a game that isn't over, since every seat's session sees the others' hands.
+	AdminToken string `json:"admin_token,omitempty"`
+}
+
+type ForkGameResponse struct {
+	Status   string         `json:"status"`
+	Reason   string         `json:"reason,omitempty"`
+	GameName string         `json:"game_name,omitempty"`
+	Players  []string       `json:"players,omitempty"`  // the same as in the old game
+	Sessions []SessionToken `json:"sessions,omitempty"` // one per player, in the same order
+}
+
+func NewForkGameResponseError(reason string) *ForkGameResponse {
+	return &ForkGameResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func ForkGame(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*ForkGameRequest)
+	if !ok {
+		return NewForkGameResponseError("cannot interpret the request as a ForkGameRequest")
+	}
+	if req.GameName == "" {
+		return NewForkGameResponseError("missing required field \"game_name\"")
+	}
+	game := state.lookupGame(req.GameName)
+	if game == nil {
+		return NewForkGameResponseError("no game found with that name")
+	}
+	name := req.NewName
+	if name == "" {
+		bs, err := RandBytes(4)
+		if err != nil {
+			return NewForkGameResponseError("error generating game name")
+		}
+		name = fmt.Sprintf("%v-fork-%v", req.GameName, hex.EncodeToString(bs))
+	}
+
+	game.Lock()
+	over := game.overallState().Over()
+	events, err := game.eventsBefore(req.Turn)
+	start := &StartGameRequest{
+		Name:          name,
+		NumPlayers:    game.NumPlayers,
+		Variant:       game.rules.ID(),
+		Seed:          game.Seed,
+		TurnTimeLimit: game.turnTimeLimit.Seconds(),
+		TimeoutAction: game.timeoutAction,
+		Substitute:    game.substitute,
+	}
+	if game.Seed == nil {
+		start.Deck = game.deal.deck
+	}
+	game.Unlock()
+	if !over && !state.checkAdminToken(req.AdminToken) {
+		return NewForkGameResponseError("not authorized to fork a game in progress")
+	}
+	if err != nil {
+		return NewForkGameResponseError(err.Error())
+	}
+
+	state.GamesMapLock.Lock()
+	fork, err := state.createGame(start, nil)
+	if err != nil {
+		state.GamesMapLock.Unlock()
+		return NewForkGameResponseError(err.Error())
+	}
+	// Locked before anyone else can find it, so no one joins halfway through.
+	fork.Lock()
+	state.GamesMapLock.Unlock()
+	err = fork.replayForked(events)
+	if err != nil {
+		// The events played out the same way once, so this shouldn't happen.
+		// Drop the half-built fork, saying so in case it was saved.
+		fork.record(LogEntry{Type: LogReap})
+		fork.Unlock()
+		state.removeGames(map[*Game]bool{fork: true})
+		return NewForkGameResponseError(err.Error())
+	}
+	res := &ForkGameResponse{
+		Status:   "ok",
+		GameName: name,
+		Players:  append([]string{}, fork.names...),
+		Sessions: append([]SessionToken{}, fork.players...),
+	}
+	fork.Unlock()
+	for _, session := range res.Sessions {
+		state.addSession(session, fork)
+	}
+	log.Printf("Forked game %v at turn %v: %v", req.GameName, req.Turn, name)
+	return res
+}
+
+// The events up to turn, so that a game with them is just about to play it.
+// Requires game is locked!
+func (g *Game) eventsBefore(turn int) ([]event, error) {
+	if turn < 0 || turn > len(g.turns) {
+		return nil, fmt.Errorf("turn must be between 0 and %v", len(g.turns))
+	}
+	if g.overallState() == NotStarted {
+		return nil, fmt.Errorf("the game hasn't started yet")
+	}
+	t := g.deal
+	for i, e := range g.events {
+		if len(t.turns) == turn && t.overallState() == InProgress {
+			return g.events[:i], nil
+		}
+		var err error
+		t, err = t.apply(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g.events, nil
+}
+
+// Play the events of another game, with a fresh session for every player who joins.
+// Requires game is locked!
+func (g *Game) replayForked(events []event) error {
+	for _, e := range events {
+		var err error
+		switch e.Type {
+		case eventJoin:
+			var session SessionToken
+			session, err = RandomSessionToken()
+			if err != nil {
+				return fmt.Errorf("error generating session token")
+			}
+			err = g.join(e.Player, session, "", "")
+		case eventLeave:
+			var session SessionToken
+			session, err = g.lookupPlayerByName(e.Player)
+			if err == nil {
+				err = g.leave(session)
+			}
+		default:
+			err = g.commit(e)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fork(s *ServerState, req ForkGameRequest) *ForkGameResponse {
+	return ForkGame(s, &req).(*ForkGameResponse)
+}
+
+func TestForkGame_Finished(t *testing.T) {
+	s := &NewServer().state
+	seed := int64(3)
+	res := StartGame(s, &StartGameRequest{NumPlayers: 3, Name: "bots", Seed: &seed, Bots: []string{"heuristic", "heuristic", "heuristic"}}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	original := s.lookupGame("bots")
+	waitForFinish(t, original)
+
+	forked := fork(s, ForkGameRequest{GameName: "bots", Turn: 17, NewName: "what-if"})
+	require.Equal(t, "ok", forked.Status, "%v", forked.Reason)
+	require.Equal(t, "what-if", forked.GameName)
+	require.Equal(t, []string{"heuristic-bot-1", "heuristic-bot-2", "heuristic-bot-3"}, forked.Players)
+	require.Len(t, forked.Sessions, 3)
+
+	game := s.lookupGame("what-if")
+	require.Len(t, game.turns, 17)
+	for i, turn := range game.turns {
+		want := original.turns[i]
+		require.Equal(t, want.Player, turn.Player)
+		require.Equal(t, want.Move.Type, turn.Move.Type)
+		require.Equal(t, want.Hints, turn.Hints)
+		require.Equal(t, want.BombsLeft, turn.BombsLeft)
+	}
+
+	// Whoever had turn 17 can make a different move now.
+	state := viewOf(t, game, forked.Sessions[17%3])
+	require.Equal(t, YourTurn, state.State)
+	require.Equal(t, original.turns[17].Player, state.WhoseTurn)
+	require.NoError(t, game.lockingMove(forked.Sessions[17%3], discardMove(state.Hand[0].ID)))
+	require.Equal(t, game, s.gameForSession(forked.Sessions[0]))
+
+	// Forking the deal with a generated name.
+	again := fork(s, ForkGameRequest{GameName: "bots"})
+	require.Equal(t, "ok", again.Status, "%v", again.Reason)
+	require.Contains(t, again.GameName, "bots-fork-")
+	require.Equal(t, faces(original.dealt), faces(s.lookupGame(again.GameName).dealt))
+
+	require.Equal(t, "error", fork(s, ForkGameRequest{GameName: "bots", Turn: len(original.turns) + 1}).Status)
+	require.Equal(t, "error", fork(s, ForkGameRequest{GameName: "bots", Turn: -1}).Status)
+	require.Equal(t, "error", fork(s, ForkGameRequest{GameName: "bots", NewName: "what-if"}).Status, "name taken")
+}
+
+func TestForkGame_InProgress(t *testing.T) {
+	server, players := setupTest(t, 2)
+	s := &server.Server.state
+	s.AdminToken = "secret"
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Discard, CardID: &one}))
+
+	require.Equal(t, "error", fork(s, ForkGameRequest{GameName: "test-game", Turn: 1}).Status)
+	forked := fork(s, ForkGameRequest{GameName: "test-game", Turn: 1, AdminToken: "secret"})
+	require.Equal(t, "ok", forked.Status, "%v", forked.Reason)
+	game := s.lookupGame(forked.GameName)
+	require.Len(t, game.turns, 1)
+	require.Equal(t, YourTurn, viewOf(t, game, forked.Sessions[1]).State)
+
+	original := s.lookupGame("test-game")
+	require.Equal(t, faces(original.handOf(players[0].Session)), faces(game.handOf(forked.Sessions[0])))
+	require.Equal(t, faces(original.handOf(players[1].Session)), faces(game.handOf(forked.Sessions[1])))
+}
+
+func TestForkGame_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Discard, CardID: &one}))
+	s := &server.Server.state
+	s.AdminToken = "secret"
+	forked := fork(s, ForkGameRequest{GameName: "test-game", Turn: 1, NewName: "fork", AdminToken: "secret"})
+	require.Equal(t, "ok", forked.Status, "%v", forked.Reason)
+
+	restored := newStoredServer(t, dir)
+	game := restored.Server.state.gameForSession(forked.Sessions[1])
+	require.NotNil(t, game)
+	require.Equal(t, s.lookupGame("fork").turns, game.turns)
+}
+
+func TestForkGame_Timeout(t *testing.T) {
+	s := &NewServer().state
+	s.AdminToken = "secret"
+	sessions := startTimedGame(t, s, 0, "")
+	original := s.lookupGame("test-game")
+	original.Lock()
+	require.NoError(t, original.timeout())
+	original.Unlock()
+	state := viewOf(t, original, sessions[1])
+	require.NoError(t, original.lockingMove(sessions[1], discardMove(state.Hand[0].ID)))
+
+	forked := fork(s, ForkGameRequest{GameName: "test-game", Turn: 1, AdminToken: "secret"})
+	require.Equal(t, "ok", forked.Status, "%v", forked.Reason)
+	game := s.lookupGame(forked.GameName)
+	require.Len(t, game.turns, 1)
+	require.True(t, game.turns[0].Timeout, "the timeout is still a timeout")
+
+	// The fork is the original just before the turn, down to the last detail.
+	original.Lock()
+	before, err := original.tableAt(len(original.events) - 1)
+	original.Unlock()
+	require.NoError(t, err)
+	require.Equal(t, before, game.table)
+	require.Equal(t, YourTurn, viewOf(t, game, forked.Sessions[1]).State)
+}
+
+func TestForkGame_ReplayFails(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	s := &server.Server.state
+	seed := int64(3)
+	res := StartGame(s, &StartGameRequest{NumPlayers: 3, Name: "bots", Seed: &seed, Bots: []string{"heuristic", "heuristic", "heuristic"}}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	original := s.lookupGame("bots")
+	waitForFinish(t, original)
+
+	// Deal the fork something else, so the hints no longer touch the same cards.
+	original.Lock()
+	other := seed + 1
+	original.Seed = &other
+	original.Unlock()
+
+	forked := fork(s, ForkGameRequest{GameName: "bots", Turn: len(original.turns), NewName: "broken"})
+	require.Equal(t, "error", forked.Status)
+	require.Empty(t, forked.Sessions)
+	require.Nil(t, s.lookupGame("broken"))
+	require.Empty(t, s.Sessions)
+
+	restored := newStoredServer(t, dir)
+	require.Nil(t, restored.Server.state.lookupGame("broken"), "it stays gone")
+}
@@ -4,123 +4,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sync"
-)
-
-type MoveType string
-
-const (
-	Hint    MoveType = "hint"
-	Play             = "play"
-	Discard          = "discard"
-)
-
-type Color string
-
-const (
-	Red    Color = "red"
-	Yellow Color = "yellow"
-	Green  Color = "green"
-	Blue   Color = "blue"
-	Black  Color = "black"
-	White  Color = "white"
-)
-
-type GameState string
-
-const (
-	NotStarted     GameState = "not-started"
-	WaitingForTurn GameState = "waiting-for-turn"
-	YourTurn       GameState = "your-turn"
-	Finished       GameState = "finished"
+	"time"
 )
 
 var Colors = [...]Color{Red, Yellow, Green, Blue, White}
 var Numbers = [...]int{1, 2, 3, 4, 5}
 
-type Move struct {
-	Type MoveType `json:"type"`
-	// for Hint:
-	ToPlayer *string `json:"to_player,omitempty"`
-	Color    *Color  `json:"color,omitempty"`
-	Number   *int    `json:"number,omitempty"`
-	CardIDs  []int   `json:"card_ids,omitempty"`
-	// for Play/Discard:
-	CardID *int `json:"card_id,omitempty"`
-}
-
-type Card struct {
-	ID     int   `json:"id"`
-	Color  Color `json:"color"`
-	Number int   `json:"number"`
-}
-
-// Card implements Cardy
-var _ Cardy = (*Card)(nil)
-
-func (f *Card) GetID() int {
-	return f.ID
-}
-
-func (f *Card) Hide() HiddenCard {
-	return HiddenCard{
-		ID: f.ID,
-	}
-}
-
-type HiddenCard struct {
-	ID int `json:"id"`
-}
-
-// HiddenCard implements Cardy
-var _ Cardy = (*HiddenCard)(nil)
-
-func (h *HiddenCard) GetID() int {
-	return h.ID
-}
-
-type Cardy interface {
-	GetID() int
-}
 type Deck []Card
 
-// Draw card into session's hand
-func (g *Game) DrawCard(session SessionToken) *Card {
-	l := len(g.deck)
-	if l == 0 {
-		return nil
-	} else if l == 1 {
-		// Drawing the last card.
-		// Game will end in len(players) turns
-		g.turnsLeft = len(g.players) + 1
-	}
-	c := g.deck[l-1]
-	g.deck = g.deck[l-2:]
-	g.hands[session] = append(g.hands[session], c)
-	return &c
-}
-
-type Turn struct {
-	ID     int    `json:"id"`     // turn number starting at 0
-	Player string `json:"player"` // player that made the move
-	Move   Move   `json:"move"`
-	// no NewCard for Hint move
-	NewCard Cardy `json:"new_card"`
-}
-
-type GameStateSummary struct {
-	State      GameState         `json:"state"`
-	Players    []string          `json:"players"`
-	Hand       []HiddenCard      `json:"hand"`        // the focused player's hand
-	OtherHands map[string][]Card `json:"other_hands"` // the other player's hands
-	Board      map[Color][]Card  `json:"board"`
-	Discard    []Card            `json:"discard"`
-	Turns      []Turn            `json:"turns"`
-	TurnCursor int               `json:"turn_cursor"`
-}
-
-// 64-bit hex
-type SessionToken string
-
 func RandomSessionToken() (res SessionToken, err error) {
 	bs, err := RandBytes(8)
 	if err != nil {
@@ -136,90 +27,90 @@ type Game struct {
 	// Immutable Fields
 	Name       string
 	NumPlayers int
+	Seed       *int64 // nil if the deal order was given explicitly
+	Created    time.Time
+	store      *Storage // nil if the game isn't saved
 	cardsByID  map[int]Card
+	deal       table // before anyone joined
+	// 0 means no limit
+	turnTimeLimit time.Duration
+	timeoutAction TimeoutAction
+	substitute    string         // built-in bot for players who leave mid-game, if any
+	fastForward   []ReplayAction // played as soon as the game is full
+
+	// The deal with every event applied. Only changed by commit.
+	table
+	events []event
 
 	// Mutable, private fields
-	players     []SessionToken
+	players     []SessionToken // by seat, the same seats as the table's
 	playerNames map[SessionToken]string
-	turns       []Turn
-	deck        Deck
-	hands       map[SessionToken][]Card
-	board       map[Color][]Card // Stack for each color
-	bombs       int
-	hints       int
-	discard     []Card
-	whoseTurn   int // Index into players. Use -1 when game is over
-	turnsLeft   int // Turns until game end. 0 means unlimited (last card hasn't been drawn)
+	changed     chan struct{}              // Closed and replaced whenever the game changes
+	lastChanged time.Time                  // When changed was last closed
+	bots        map[SessionToken]Player    // seats the server plays itself
+	secrets     map[SessionToken]string    // hashes of the secrets players joined with, to rejoin
+	spectators  map[SessionToken]spectator // who each spectator sees the game as
+	turnTimer   *time.Timer                // times out the current turn, nil if there's no limit
+	turnEnds    time.Time                  // when turnTimer fires
+	replaying   bool                       // being restored from storage, the clock doesn't run
 }
 
-func (g *Game) cardsInHand() int {
-	if g.NumPlayers <= 3 {
-		return 5
-	}
-	return 4
+// A channel that is closed the next time the game changes.
+func (g *Game) lockingChanged() <-chan struct{} {
+	g.Lock()
+	defer g.Unlock()
+	return g.changed
 }
 
-// Removes the card from the hand!
+// Wake up everyone waiting on lockingChanged.
 // Requires game is locked!
-func (g *Game) getCardFromHand(cardID int, player SessionToken) *Card {
-	hand := g.hands[player]
-	for i, card := range hand {
-		if card.ID == cardID {
-			g.hands[player] = append(hand[:i], hand[i+1:]...)
-			return &card
-		}
-	}
-	return nil
+func (g *Game) notifyChanged() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+	g.lastChanged = time.Now()
 }
 
-func (g *Game) checkCardColor(color Color) error {
-	switch color {
-	case Red, Yellow, Green, Blue, Black, White:
-	default:
-		return fmt.Errorf("invalid color: %v", color)
-	}
-	return nil
+// Apply e to the table, add it to the events and save it. Nothing changes on error.
+// This is the only way the table changes, so the saved events rebuild it exactly.
+// Requires game is locked!
+func (g *Game) commit(e event) error {
+	return g.commitSaving(e, LogEntry{})
 }
 
-func (g *Game) checkCardNumber(number int) error {
-	switch number {
-	case 1, 2, 3, 4, 5:
-	default:
-		return fmt.Errorf("invalid number: %v", number)
+// Like commit, and saves entry's fields in the same line as the event,
+// so they're never saved without each other.
+// Requires game is locked!
+func (g *Game) commitSaving(e event, entry LogEntry) error {
+	t, err := g.table.apply(e)
+	if err != nil {
+		return err
 	}
+	g.table = t
+	g.events = append(g.events, e)
+	entry.Type = LogEvent
+	entry.Event = &e
+	g.record(entry)
+	g.notifyChanged()
+	g.startTurnTimer()
 	return nil
 }
 
-func (g *Game) Score() int {
-	score := 0
-	for _, pile := range g.board {
-		if len(pile) > 0 {
-			topCard := pile[len(pile)-1]
-			score += topCard.Number
-		}
+// The table after the first n events, rebuilt from the deal.
+// Requires game is locked!
+func (g *Game) tableAt(n int) (table, error) {
+	if n < 0 || n > len(g.events) {
+		return table{}, fmt.Errorf("no event %v, there are %v", n, len(g.events))
 	}
-	return score
+	return replayEvents(g.deal, g.events[:n])
 }
 
-func (g *Game) commitTurn(turn Turn, gameOver bool) {
-	g.turns = append(g.turns, turn)
-	if g.turnsLeft == 1 || gameOver {
-		// This is the last turn, game over.
-		g.whoseTurn = -1
-		g.turnsLeft = 0
-		return
-	}
-	if g.turnsLeft > 0 {
-		g.turnsLeft--
-	}
-	if g.Score() == 25 {
-		// Game over because you win
-		g.whoseTurn = -1
-	}
-	if g.whoseTurn >= 0 {
-		g.whoseTurn++
-		if g.whoseTurn >= len(g.players) {
-			g.whoseTurn = 0
+// The cards in a player's hand, nil for anyone without a seat.
+// Requires game is locked!
+func (g *Game) handOf(session SessionToken) []Card {
+	for i, s := range g.players {
+		if s == session {
+			return g.hands[i]
 		}
 	}
+	return nil
 }
@@ -1,20 +1,9 @@
 package main
 
 import (
-	"time"
+	"fmt"
 )
 
-type GetStateRequest struct {
-	Session SessionToken `json:"session"`
-	Wait    bool         `json:"wait"`
-}
-
-type GetStateResponse struct {
-	Status string           `json:"status"`
-	Reason string           `json:"reason,omitempty"`
-	State  GameStateSummary `json:"state,omitempty"`
-}
-
 func NewGetStateResponseError(reason string) *GetStateResponse {
 	return &GetStateResponse{
 		Status: "error",
@@ -32,8 +21,15 @@ func GetState(state *ServerState, req_ interface{}) interface{} {
 		return NewGetStateResponseError("Session token not found")
 	}
 
+	if req.TurnCursor != nil && *req.TurnCursor < 0 {
+		return NewGetStateResponseError("turn_cursor must not be negative")
+	}
+
 	// Blocks iff req.Wait
-	gameState := getStateLoop(game, req.Session, req.Wait)
+	gameState, err := getStateLoop(game, req.Session, req.Wait, req.TurnCursor)
+	if err != nil {
+		return NewGetStateResponseError(err.Error())
+	}
 
 	return &GetStateResponse{
 		Status: "ok",
@@ -41,21 +37,54 @@ func GetState(state *ServerState, req_ interface{}) interface{} {
 	}
 }
 
-func getStateLoop(g *Game, session SessionToken, wait bool) GameStateSummary {
+// When waiting, blocks until it's the player's turn or the game is over.
+// With a turn cursor it also stops waiting as soon as there is a turn after the cursor.
+func getStateLoop(g *Game, session SessionToken, wait bool, turnCursor *int) (GameStateSummary, error) {
+	cursor := 0
+	if turnCursor != nil {
+		cursor = *turnCursor
+	}
 	for {
-		res := g.getState(session, 0)
+		// Grab the channel before reading the state so no change can slip in between.
+		changed := g.lockingChanged()
+		res, err := g.getState(session, cursor)
+		if err != nil {
+			return res, err
+		}
+		if cursor > res.TurnCursor {
+			return res, fmt.Errorf("turn_cursor %v is past the last turn (%v)", cursor, res.TurnCursor)
+		}
 
-		if !wait || res.State == YourTurn {
-			return res
+		if !wait || res.State == YourTurn || res.State.Over() {
+			return res, nil
 		}
-		// TODO use a channel to make this faster
-		<-time.NewTimer(time.Millisecond * 500).C
+		if turnCursor != nil && len(res.Turns) > 0 {
+			return res, nil
+		}
+		<-changed
 	}
 }
 
-func (g *Game) getState(session SessionToken, turnCursor int) GameStateSummary {
+// The game as session sees it. Errors for a session that's neither seated
+// nor spectating, like one that's been rotated away, and for a spectator
+// whose player has left.
+func (g *Game) getState(session SessionToken, turnCursor int) (GameStateSummary, error) {
 	g.Lock()
 	defer g.Unlock()
+	spec, spectating := g.spectators[session]
+	if spectating && spec.seesAll {
+		// No session matches, so every hand is shown and nothing is redacted.
+		session = ""
+	} else if spectating {
+		// See what that player sees, for as long as they're in the game.
+		var err error
+		session, err = g.lookupPlayerByName(spec.asPlayer)
+		if err != nil {
+			return GameStateSummary{}, fmt.Errorf("%v has left the game", spec.asPlayer)
+		}
+	} else if _, _, err := g.playerInfo(session); err != nil {
+		return GameStateSummary{}, fmt.Errorf("Session token not found")
+	}
 
 	var resp GameStateSummary
 	// fill these no matter what
@@ -67,27 +96,40 @@ func (g *Game) getState(session SessionToken, turnCursor int) GameStateSummary {
 	resp.Discard = g.discard
 	resp.Hand = g.hiddenPlayerHand(session)
 	resp.OtherHands = g.otherHands(session)
+	resp.Hints = g.hints
+	resp.BombsLeft = g.bombs
+	resp.DeckSize = len(g.deck)
+	resp.TurnsLeft = g.turnsLeft
+	resp.Score = g.Score()
+	resp.Seed = g.Seed
+	resp.Variant = g.rules.ID()
+	resp.TurnTimeLeft = g.turnTimeLeft()
 
-	if len(g.players) < g.NumPlayers {
+	if g.overallState() == NotStarted {
 		// Game has not started yet
 		resp.State = NotStarted
 		if len(resp.Turns) == 0 {
 			resp.Turns = []Turn{}
 		}
-		return resp
+		return resp, nil
 	} else if g.whoseTurn == -1 {
-		resp.State = Finished
-	} else if g.players[g.whoseTurn] == session {
+		resp.State = g.overallState()
+	} else if g.players[g.whoseTurn] == session && !spectating {
 		resp.State = YourTurn
+		resp.WhoseTurn = g.playerNames[session]
 	} else {
 		resp.State = WaitingForTurn
+		resp.WhoseTurn = g.playerNames[g.players[g.whoseTurn]]
 	}
-	resp.Turns = g.turns[turnCursor:]
+	if turnCursor > len(g.turns) {
+		turnCursor = len(g.turns)
+	}
+	resp.Turns = g.redactedTurns(session, g.turns[turnCursor:])
 	if len(resp.Turns) == 0 {
 		resp.Turns = []Turn{}
 	}
 	resp.TurnCursor = len(g.turns)
-	return resp
+	return resp, nil
 }
 
 func (g *Game) exportBoard() map[Color][]Card {
@@ -105,19 +147,32 @@ func (g *Game) exportBoard() map[Color][]Card {
 // The hands of the players _except_ the specified player.
 func (g *Game) otherHands(exceptPlayer SessionToken) map[string][]Card {
 	res := make(map[string][]Card)
-	for p2, hand := range g.hands {
+	for seat, p2 := range g.players {
 		if p2 == exceptPlayer {
 			continue
 		}
-		res[g.playerNames[p2]] = hand
+		res[g.names[seat]] = g.hands[seat]
+	}
+	return res
+}
+
+// The turns as a player sees them: without the faces of the cards they drew.
+func (g *Game) redactedTurns(player SessionToken, turns []Turn) []Turn {
+	name := g.playerNames[player]
+	res := make([]Turn, len(turns))
+	for i, turn := range turns {
+		if card, ok := turn.NewCard.(*Card); ok && card != nil && turn.Player == name {
+			hidden := card.Hide()
+			turn.NewCard = &hidden
+		}
+		res[i] = turn
 	}
 	return res
 }
 
 // The hand of a player, as hidden cards
 func (g *Game) hiddenPlayerHand(player SessionToken) (res []HiddenCard) {
-	hand := g.hands[player]
-	for _, card := range hand {
+	for _, card := range g.handOf(player) {
 		res = append(res, card.Hide())
 	}
 	return res
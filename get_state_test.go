@@ -2,17 +2,27 @@ package main
 
 import "testing"
 
-func serverGamePlayer() (ServerState, *Game, SessionToken) {
-	s := NewServer().state
-	StartGame(&s, &StartGameRequest{2, "test_game"})
-	r := JoinGame(&s, &JoinGameRequest{"test_game", "player1"}).(*JoinGameResponse)
+func serverGamePlayer() (*ServerState, *Game, SessionToken) {
+	s := &NewServer().state
+	StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "test_game"})
+	r := JoinGame(s, &JoinGameRequest{GameName: "test_game", PlayerName: "player1"}).(*JoinGameResponse)
 	return s, s.Games["test_game"], r.Session
 }
 
+// The game as session sees it, failing the test if it can't see it.
+func viewOf(t *testing.T, g *Game, session SessionToken) GameStateSummary {
+	t.Helper()
+	state, err := g.getState(session, 0)
+	if err != nil {
+		t.Fatalf("%v can't see the game: %v", session, err)
+	}
+	return state
+}
+
 func TestGetState_NotStarted(t *testing.T) {
 	serverState, _, session := serverGamePlayer()
-	request := GetStateRequest{session, false}
-	response := GetState(&serverState, &request).(*GetStateResponse)
+	request := GetStateRequest{Session: session}
+	response := GetState(serverState, &request).(*GetStateResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -46,9 +56,9 @@ func TestGetState_NotStarted(t *testing.T) {
 
 func TestGetState_YourTurn(t *testing.T) {
 	serverState, _, session := serverGamePlayer()
-	JoinGame(&serverState, &JoinGameRequest{"test_game", "player2"})
-	request := GetStateRequest{session, false}
-	response := GetState(&serverState, &request).(*GetStateResponse)
+	JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	request := GetStateRequest{Session: session}
+	response := GetState(serverState, &request).(*GetStateResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -89,10 +99,10 @@ func TestGetState_YourTurn(t *testing.T) {
 
 func TestGetState_WaitingForTurn(t *testing.T) {
 	serverState, _, session := serverGamePlayer()
-	r := JoinGame(&serverState, &JoinGameRequest{"test_game", "player2"})
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
 	session = r.(*JoinGameResponse).Session
-	request := GetStateRequest{session, false}
-	response := GetState(&serverState, &request).(*GetStateResponse)
+	request := GetStateRequest{Session: session}
+	response := GetState(serverState, &request).(*GetStateResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -130,3 +140,227 @@ func TestGetState_WaitingForTurn(t *testing.T) {
 		t.Errorf("Expect there are no turns")
 	}
 }
+
+func TestGetState_Wait(t *testing.T) {
+	serverState, _, session1 := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	session2 := r.(*JoinGameResponse).Session
+
+	done := make(chan *GetStateResponse)
+	go func() {
+		request := GetStateRequest{Session: session2, Wait: true}
+		done <- GetState(serverState, &request).(*GetStateResponse)
+	}()
+
+	one := 1
+	MoveHandler(serverState, &MoveRequest{Session: session1, Move: Move{Type: Play, CardID: &one}})
+	response := <-done
+	if s := response.State.State; s != "your-turn" {
+		t.Errorf("Expected the wait to end on 'your-turn' but state is %v", s)
+	}
+	if l := len(response.State.Turns); l != 1 {
+		t.Errorf("Expected 1 turn but there are %v", l)
+	}
+}
+
+func TestGetState_TurnCursor(t *testing.T) {
+	serverState, _, session1 := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	session2 := r.(*JoinGameResponse).Session
+
+	one := 1
+	MoveHandler(serverState, &MoveRequest{Session: session1, Move: Move{Type: Play, CardID: &one}})
+	eight := 8
+	MoveHandler(serverState, &MoveRequest{Session: session2, Move: Move{Type: Discard, CardID: &eight}})
+
+	cursor := 1
+	request := GetStateRequest{Session: session1, TurnCursor: &cursor}
+	response := GetState(serverState, &request).(*GetStateResponse)
+	if response.Status == "error" {
+		t.Fatalf("Expected status ok but was error: %v", response.Reason)
+	}
+	if l := len(response.State.Turns); l != 1 {
+		t.Fatalf("Expected only the turn after the cursor but got %v turns", l)
+	}
+	if id := response.State.Turns[0].ID; id != 1 {
+		t.Errorf("Expected turn 1 but got turn %v", id)
+	}
+	if c := response.State.TurnCursor; c != 2 {
+		t.Errorf("Expected the new cursor to be 2 but was %v", c)
+	}
+
+	cursor = 3
+	response = GetState(serverState, &request).(*GetStateResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for a cursor past the last turn")
+	}
+	cursor = -1
+	response = GetState(serverState, &request).(*GetStateResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for a negative cursor")
+	}
+}
+
+func TestGetState_WaitForTurnAfterCursor(t *testing.T) {
+	serverState, _, session1 := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	session2 := r.(*JoinGameResponse).Session
+
+	one := 1
+	MoveHandler(serverState, &MoveRequest{Session: session1, Move: Move{Type: Play, CardID: &one}})
+
+	// It's player2's turn, so player1 waits for player2's move to show up.
+	done := make(chan *GetStateResponse)
+	go func() {
+		cursor := 1
+		request := GetStateRequest{Session: session1, Wait: true, TurnCursor: &cursor}
+		done <- GetState(serverState, &request).(*GetStateResponse)
+	}()
+
+	eight := 8
+	MoveHandler(serverState, &MoveRequest{Session: session2, Move: Move{Type: Discard, CardID: &eight}})
+	response := <-done
+	if l := len(response.State.Turns); l != 1 {
+		t.Fatalf("Expected player2's turn but got %v turns", l)
+	}
+	if p := response.State.Turns[0].Player; p != "player2" {
+		t.Errorf("Expected the turn was player2's but was %v", p)
+	}
+}
+
+func TestGetState_Counters(t *testing.T) {
+	serverState, game, session1 := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	session2 := r.(*JoinGameResponse).Session
+
+	response := GetState(serverState, &GetStateRequest{Session: session2}).(*GetStateResponse)
+	if h := response.State.Hints; h != 8 {
+		t.Errorf("Expected 8 hints but there are %v", h)
+	}
+	if b := response.State.BombsLeft; b != 3 {
+		t.Errorf("Expected 3 bombs left but there are %v", b)
+	}
+	if d := response.State.DeckSize; d != 40 {
+		t.Errorf("Expected 40 cards in the deck but there are %v", d)
+	}
+	if s := response.State.Score; s != 0 {
+		t.Errorf("Expected a score of 0 but it's %v", s)
+	}
+	if p := response.State.WhoseTurn; p != "player1" {
+		t.Errorf("Expected it's player1's turn but it's %v's", p)
+	}
+
+	one := 1
+	MoveHandler(serverState, &MoveRequest{Session: session1, Move: Move{Type: Play, CardID: &one}})
+	response = GetState(serverState, &GetStateRequest{Session: session2}).(*GetStateResponse)
+	if d := response.State.DeckSize; d != 39 {
+		t.Errorf("Expected 39 cards in the deck but there are %v", d)
+	}
+	if p := response.State.WhoseTurn; p != "player2" {
+		t.Errorf("Expected it's player2's turn but it's %v's", p)
+	}
+	if s := response.State.Score; s != game.Score() {
+		t.Errorf("Expected a score of %v but it's %v", game.Score(), s)
+	}
+	if b := response.State.BombsLeft; b != game.bombs {
+		t.Errorf("Expected %v bombs left but there are %v", game.bombs, b)
+	}
+	if l := len(response.State.Hand) + len(response.State.OtherHands["player1"]); l != 10 {
+		t.Errorf("Expected 10 cards in hands but there are %v", l)
+	}
+}
+
+func TestGetState_NeverSeeOwnCards(t *testing.T) {
+	serverState, game, session1 := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2"})
+	session2 := r.(*JoinGameResponse).Session
+	sessions := map[SessionToken]string{session1: "player1", session2: "player2"}
+
+	// Everyone discards their first card until the game ends, checking every view after every turn.
+	for {
+		for session, name := range sessions {
+			response := GetState(serverState, &GetStateRequest{Session: session}).(*GetStateResponse)
+			if _, ok := response.State.OtherHands[name]; ok {
+				t.Fatalf("%v can see their own hand", name)
+			}
+			for _, turn := range response.State.Turns {
+				if turn.Player != name || turn.NewCard == nil {
+					continue
+				}
+				if card, ok := turn.NewCard.(*Card); ok && card != nil {
+					t.Fatalf("%v can see the card they drew on turn %v: %v", name, turn.ID, card)
+				}
+			}
+		}
+
+		response := GetState(serverState, &GetStateRequest{Session: session1}).(*GetStateResponse)
+		if response.State.State == "finished" {
+			break
+		}
+		session := session1
+		if response.State.State != "your-turn" {
+			session = session2
+		}
+		cardID := game.handOf(session)[0].ID
+		res := MoveHandler(serverState, &MoveRequest{Session: session, Move: Move{Type: Discard, CardID: &cardID}}).(*MoveResponse)
+		if res.Status != "ok" {
+			t.Fatalf("Expected the discard to work but got %v", res.Reason)
+		}
+	}
+
+	// The other player still sees every card that was drawn.
+	response := GetState(serverState, &GetStateRequest{Session: session2}).(*GetStateResponse)
+	for _, turn := range response.State.Turns {
+		if turn.Player != "player1" {
+			continue
+		}
+		if card, ok := turn.NewCard.(*Card); ok && card == nil {
+			continue // nothing left to draw
+		}
+		if _, ok := turn.NewCard.(*Card); !ok {
+			t.Errorf("player2 should see the card player1 drew on turn %v but sees %v", turn.ID, turn.NewCard)
+		}
+	}
+}
+
+func TestGetState_RotatedSession(t *testing.T) {
+	serverState, game, _ := serverGamePlayer()
+	r := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2", Secret: "hunter2"})
+	old := r.(*JoinGameResponse).Session
+
+	// Both wait on player2's old session for player1 to move.
+	waited := make(chan *GetStateResponse)
+	go func() {
+		waited <- GetState(serverState, &GetStateRequest{Session: old, Wait: true}).(*GetStateResponse)
+	}()
+	watched := make(chan *WatchMessage, 10)
+	go func() {
+		watchGame(game, old, 0, nil, func(msg interface{}) error {
+			watched <- msg.(*WatchMessage)
+			return nil
+		})
+		close(watched)
+	}()
+
+	rotated := JoinGame(serverState, &JoinGameRequest{GameName: "test_game", PlayerName: "player2", Secret: "hunter2", RotateSession: true}).(*JoinGameResponse)
+	if rotated.Status != "ok" {
+		t.Fatalf("Expected the rotation to work but got %v", rotated.Reason)
+	}
+
+	if response := <-waited; response.Status != "error" {
+		t.Errorf("Expected the old session's wait to end in an error but it sees %v", response.State)
+	}
+	var last *WatchMessage
+	for msg := range watched {
+		last = msg
+	}
+	if last == nil || last.Status != "error" {
+		t.Errorf("Expected the old session's watch to end in an error but got %v", last)
+	}
+	if _, err := game.getState(old, 0); err == nil {
+		t.Errorf("Expected the old session to see nothing")
+	}
+	if response := GetState(serverState, &GetStateRequest{Session: rotated.Session}).(*GetStateResponse); response.Status != "ok" {
+		t.Errorf("Expected the new session to see the game but got %v", response.Reason)
+	}
+}
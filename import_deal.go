@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// The variant a hanab.live replay was played in.
+func replayVariant(replay *Replay) (Variant, error) {
+	for variant, name := range hanabLiveVariants {
+		if name == replay.Options.Variant {
+			return variant, nil
+		}
+	}
+	if replay.Options.Variant == "" {
+		// hanab.live leaves it out for the standard game.
+		return Standard, nil
+	}
+	return "", fmt.Errorf("can't import the %q variant", replay.Options.Variant)
+}
+
+func replayDeck(replay *Replay, rules RuleSet) ([]Card, error) {
+	colors := rules.Colors()
+	deck := make([]Card, len(replay.Deck))
+	for i, card := range replay.Deck {
+		if card.SuitIndex < 0 || card.SuitIndex >= len(colors) {
+			return nil, fmt.Errorf("card %v has an invalid suitIndex: %v", i, card.SuitIndex)
+		}
+		deck[i] = Card{Color: colors[card.SuitIndex], Number: card.Rank}
+	}
+	return deck, nil
+}
+
+// The order that deals deck, which must have exactly the rule set's cards.
+func orderForDeck(deck []Card, rules RuleSet) ([]int, error) {
+	type face struct {
+		color  Color
+		number int
+	}
+	cards := rules.Deck()
+	if len(deck) != len(cards) {
+		return nil, fmt.Errorf("deck must have %v cards but has %v", len(cards), len(deck))
+	}
+	positions := make(map[face][]int)
+	for pos, card := range deck {
+		f := face{card.Color, card.Number}
+		positions[f] = append(positions[f], pos)
+	}
+	order := make([]int, len(cards))
+	for p, card := range cards {
+		f := face{card.Color, card.Number}
+		if len(positions[f]) == 0 {
+			return nil, fmt.Errorf("deck doesn't have the cards of the %v variant", rules.ID())
+		}
+		order[p] = positions[f][0]
+		positions[f] = positions[f][1:]
+	}
+	return order, nil
+}
+
+// The replay's actions to fast-forward through, after checking that they can
+// all be played on the deal.
+func checkFastForward(req *StartGameRequest, rules RuleSet, order []int) ([]ReplayAction, error) {
+	if req.FastForward > len(req.Replay.Actions) {
+		return nil, fmt.Errorf("can't fast-forward %v actions, the replay only has %v", req.FastForward, len(req.Replay.Actions))
+	}
+	actions := req.Replay.Actions[:req.FastForward]
+	scratch := newGame("fast-forward", req.NumPlayers, rules, nil, order)
+	for i := 0; i < req.NumPlayers; i++ {
+		session, err := RandomSessionToken()
+		if err != nil {
+			return nil, fmt.Errorf("error generating session token")
+		}
+		err = scratch.join(fmt.Sprintf("player-%v", i+1), session, "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return actions, scratch.playReplayActions(actions)
+}
+
+// Play hanab.live actions, with seats and cards numbered the way they were dealt.
+// Requires game is locked!
+func (g *Game) playReplayActions(actions []ReplayAction) error {
+	cardIDs := make(map[int]int) // index into the dealt deck -> card ID
+	for i, card := range g.dealt {
+		cardIDs[i] = card.ID
+	}
+	colors := g.rules.Colors()
+	for i, action := range actions {
+		if g.overallState() != InProgress {
+			return fmt.Errorf("action %v: the game is already over", i)
+		}
+		session := g.players[g.whoseTurn]
+		var move Move
+		switch action.Type {
+		case replayPlay, replayDiscard:
+			id, ok := cardIDs[action.Target]
+			if !ok {
+				return fmt.Errorf("action %v: no card %v in the deck", i, action.Target)
+			}
+			move = playMove(id)
+			if action.Type == replayDiscard {
+				move = discardMove(id)
+			}
+		case replayColorClue, replayRankClue:
+			if action.Target < 0 || action.Target >= len(g.players) {
+				return fmt.Errorf("action %v: no player in seat %v", i, action.Target)
+			}
+			if g.hints == 0 {
+				// hanab.live only allows a clue when there's one left, so it must
+				// have been given back by a discard, which doesn't happen here.
+				return fmt.Errorf("action %v: no clues left, hanab.live only had one because discards give clues back there", i)
+			}
+			toPlayer := g.names[action.Target]
+			move = Move{Type: Hint, ToPlayer: &toPlayer}
+			value := action.Value
+			if action.Type == replayColorClue {
+				if value < 0 || value >= len(colors) {
+					return fmt.Errorf("action %v: invalid color %v", i, value)
+				}
+				move.Color = &colors[value]
+			} else {
+				move.Number = &value
+			}
+			// hanab.live clues touch every matching card, so list them all.
+			for _, card := range g.hands[action.Target] {
+				if move.Color != nil && g.rules.ColorTouches(*move.Color, card) ||
+					move.Number != nil && g.rules.NumberTouches(*move.Number, card) {
+					move.CardIDs = append(move.CardIDs, card.ID)
+				}
+			}
+		default:
+			return fmt.Errorf("action %v: can't fast-forward through action type %v", i, action.Type)
+		}
+		err := g.move(session, move)
+		if err != nil {
+			return fmt.Errorf("action %v: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Requires game is locked!
+func (g *Game) playFastForward() {
+	err := g.playReplayActions(g.fastForward)
+	if err != nil {
+		// It was checked when the game started, so this shouldn't happen.
+		log.Printf("Error: fast-forwarding game %v: %v", g.Name, err)
+	}
+	g.fastForward = nil
+}
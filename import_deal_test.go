@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// A finished 3-player rainbow game between bots, and its replay.
+func finishedBotGame(t *testing.T) (*GameDump, *Replay) {
+	s := &NewServer().state
+	seed := int64(3)
+	res := StartGame(s, &StartGameRequest{NumPlayers: 3, Name: "bots", Seed: &seed, Variant: Rainbow, Bots: []string{"heuristic", "heuristic", "heuristic"}}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	game := s.lookupGame("bots")
+	waitForFinish(t, game)
+	exported := exportGame(s, "bots")
+	require.Equal(t, "ok", exported.Status, "%v", exported.Reason)
+	return game.lockingDump(), exported.Replay
+}
+
+func startAndFill(t *testing.T, s *ServerState, req StartGameRequest) *Game {
+	res := StartGame(s, &req).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	game := s.lookupGame(req.Name)
+	for i := len(game.players); i < game.NumPlayers; i++ {
+		_, err := game.lockingJoinGame(botName("human", i), "")
+		require.NoError(t, err)
+	}
+	return game
+}
+
+func faces(cards []Card) []Card {
+	res := make([]Card, len(cards))
+	for i, card := range cards {
+		res[i] = Card{Color: card.Color, Number: card.Number}
+	}
+	return res
+}
+
+func TestImport_Replay(t *testing.T) {
+	original, replay := finishedBotGame(t)
+	s := &NewServer().state
+	game := startAndFill(t, s, StartGameRequest{Name: "again", Replay: replay})
+	require.Equal(t, 3, game.NumPlayers, "from the replay")
+	require.Equal(t, Rainbow, game.rules.ID(), "from the replay")
+	require.Nil(t, game.Seed)
+	require.Equal(t, faces(original.Dealt), faces(game.dealt))
+	require.Empty(t, game.turns)
+}
+
+func TestImport_FastForward(t *testing.T) {
+	original, replay := finishedBotGame(t)
+	s := &NewServer().state
+	game := startAndFill(t, s, StartGameRequest{Name: "again", Replay: replay, FastForward: 10})
+	require.Len(t, game.turns, 10)
+	for i, turn := range game.turns {
+		want := original.Turns[i]
+		require.Equal(t, want.Move.Type, turn.Move.Type)
+		require.Equal(t, want.Result, turn.Result)
+		require.Equal(t, want.Hints, turn.Hints)
+		require.Equal(t, want.BombsLeft, turn.BombsLeft)
+		if want.Card != nil {
+			require.Equal(t, want.Card.Color, turn.Card.Color)
+			require.Equal(t, want.Card.Number, turn.Card.Number)
+		}
+	}
+	require.Equal(t, 10%3, game.whoseTurn)
+}
+
+func TestImport_Deck(t *testing.T) {
+	deck := faces(standardRules{}.Deck())
+	// Put the 5s on top.
+	for i, j := 0, len(deck)-1; i < j; i, j = i+1, j-1 {
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+	s := &NewServer().state
+	game := startAndFill(t, s, StartGameRequest{Name: "deck", NumPlayers: 2, Deck: deck})
+	require.Equal(t, deck[:5], faces(game.hands[0]))
+	require.Equal(t, deck[5:10], faces(game.hands[1]))
+}
+
+func TestImport_Errors(t *testing.T) {
+	_, replay := finishedBotGame(t)
+	deck := faces(standardRules{}.Deck())
+	seed := int64(1)
+	badDeck := append([]Card{}, deck...)
+	badDeck[0] = Card{Color: Black, Number: 1}
+	badAction := *replay
+	badAction.Actions = []ReplayAction{{Type: replayPlay, Target: 99}}
+	badVariant := *replay
+	badVariant.Options.Variant = "Black (6 Suits)"
+
+	s := &NewServer().state
+	for _, req := range []StartGameRequest{
+		{NumPlayers: 2, Deck: deck[1:]},
+		{NumPlayers: 2, Deck: badDeck},
+		{NumPlayers: 2, Deck: deck, Seed: &seed},
+		{NumPlayers: 2, Deck: deck, FastForward: 1},
+		{Replay: replay, Variant: Standard},
+		{Replay: replay, FastForward: len(replay.Actions) + 1},
+		{Replay: &badAction, FastForward: 1},
+		{Replay: &badVariant},
+	} {
+		req.Name = "bad"
+		res := StartGame(s, &req).(*StartGameResponse)
+		require.Equal(t, "error", res.Status, "%+v", req)
+	}
+}
+
+func TestImport_HanabLiveClueRules(t *testing.T) {
+	live := loadReplay(t, "hanab-live-discard-then-clue.json")
+	s := &NewServer().state
+	res := StartGame(s, &StartGameRequest{Name: "live", Replay: live, FastForward: 10}).(*StartGameResponse)
+	require.Equal(t, "error", res.Status)
+	require.Contains(t, res.Reason, "action 9", "bob clues with the clue alice's discard gave back on hanab.live")
+	require.Contains(t, res.Reason, "hanab.live")
+
+	// Everything up to that clue plays the same.
+	game := startAndFill(t, s, StartGameRequest{Name: "live", Replay: live, FastForward: 9})
+	require.Len(t, game.turns, 9)
+	require.Equal(t, Discard, game.turns[8].Move.Type)
+}
+
+func TestImport_Restore(t *testing.T) {
+	_, replay := finishedBotGame(t)
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	game := startAndFill(t, &server.Server.state, StartGameRequest{Name: "again", Replay: replay, FastForward: 5})
+
+	restored := newStoredServer(t, dir)
+	after := restored.Server.state.lookupGame("again")
+	require.Equal(t, game.turns, after.turns)
+	require.Equal(t, game.dealt, after.dealt)
+}
@@ -1,21 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 )
 
-type JoinGameRequest struct {
-	GameName   string `json:"game_name"`
-	PlayerName string `json:"player_name"`
-}
-
-type JoinGameResponse struct {
-	Status  string       `json:"status"`
-	Reason  string       `json:"reason,omitempty"`
-	Session SessionToken `json:"session,omitempty"`
-}
-
 func NewJoinGameResponseError(reason string) *JoinGameResponse {
 	return &JoinGameResponse{
 		Status: "error",
@@ -38,7 +31,23 @@ func JoinGame(state *ServerState, req_ interface{}) interface{} {
 	if req.PlayerName == "" {
 		return NewJoinGameResponseError("missing required field \"player_name\"")
 	}
-	session, err := game.lockingJoinGame(req.PlayerName)
+	if req.Secret != "" {
+		old, session, err := game.lockingRejoin(req.PlayerName, req.Secret, req.RotateSession)
+		if err == nil {
+			if old != session {
+				state.replaceSession(old, session)
+			}
+			log.Printf("Player rejoined %v -> %v", req.PlayerName, req.GameName)
+			return &JoinGameResponse{
+				Status:  "ok",
+				Session: session,
+			}
+		}
+		if err != errNotJoined {
+			return NewJoinGameResponseError(err.Error())
+		}
+	}
+	session, err := game.lockingJoinGame(req.PlayerName, req.Secret)
 	if err != nil {
 		return NewJoinGameResponseError(err.Error())
 	}
@@ -50,30 +59,124 @@ func JoinGame(state *ServerState, req_ interface{}) interface{} {
 	}
 }
 
-func (g *Game) lockingJoinGame(playerName string) (session SessionToken, err error) {
+// secret is optional, see lockingRejoin.
+func (g *Game) lockingJoinGame(playerName string, secret string) (session SessionToken, err error) {
+	session, err = RandomSessionToken()
+	if err != nil {
+		return session, fmt.Errorf("error generating session token")
+	}
+	return session, g.lockingJoinGameAs(playerName, session, hashSecret(secret))
+}
+
+// Join with a session token that was already chosen.
+func (g *Game) lockingJoinGameAs(playerName string, session SessionToken, secretHash string) error {
 	g.Lock()
 	defer g.Unlock()
+	return g.join(playerName, session, "", secretHash)
+}
+
+// botStrategy is empty unless the seat is played by a built-in bot.
+// secretHash is empty unless the player can rejoin.
+// Requires game is locked!
+func (g *Game) join(playerName string, session SessionToken, botStrategy string, secretHash string) error {
+	err := g.commitSaving(event{Type: eventJoin, Player: playerName},
+		LogEntry{Session: session, Bot: botStrategy, SecretHash: secretHash})
+	if err != nil {
+		return err
+	}
+	g.seat(playerName, session, botStrategy, secretHash)
+	if len(g.players) == g.NumPlayers && len(g.fastForward) > 0 {
+		g.playFastForward()
+	}
+	return nil
+}
 
-	if len(g.players) >= g.NumPlayers {
-		return session, fmt.Errorf("the game is full (%v/%v players)", len(g.players), g.NumPlayers)
+// Give the seat a player was just dealt into to their session.
+// Requires game is locked!
+func (g *Game) seat(playerName string, session SessionToken, botStrategy string, secretHash string) {
+	if botStrategy != "" {
+		g.addBot(botStrategy, session, len(g.players))
 	}
-	for _, p := range g.playerNames {
-		if p == playerName {
-			return session, fmt.Errorf("player with that name is already in the game")
+	if secretHash != "" {
+		if g.secrets == nil {
+			g.secrets = make(map[SessionToken]string)
 		}
+		g.secrets[session] = secretHash
+	}
+	g.players = append(g.players, session)
+	g.playerNames[session] = playerName
+}
+
+var errNotJoined = errors.New("no player with that name has joined")
+
+// Get back into a seat that was joined with the same secret.
+// Returns the old session and the one to use from now on, which is new if rotate is set.
+func (g *Game) lockingRejoin(playerName string, secret string, rotate bool) (old SessionToken, session SessionToken, err error) {
+	g.Lock()
+	defer g.Unlock()
+	old, err = g.lookupPlayerByName(playerName)
+	if err != nil {
+		return old, old, errNotJoined
+	}
+	hash, ok := g.secrets[old]
+	if !ok {
+		return old, old, fmt.Errorf("player with that name is already in the game")
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashSecret(secret))) != 1 {
+		return old, old, fmt.Errorf("wrong secret for that player")
+	}
+	if !rotate {
+		return old, old, nil
 	}
 	session, err = RandomSessionToken()
 	if err != nil {
-		return session, fmt.Errorf("error generating session token")
+		return old, old, fmt.Errorf("error generating session token")
 	}
-	g.players = append(g.players, session)
-	g.playerNames[session] = playerName
+	return old, session, g.rotateSession(playerName, session)
+}
 
-	// Deal cards to player
-	c := g.cardsInHand()
-	hand := g.deck[:c]
-	g.deck = g.deck[c:]
-	g.hands[session] = hand
+// Give a player a new session. Returns the old one.
+func (g *Game) lockingRotateSession(playerName string, session SessionToken) (SessionToken, error) {
+	g.Lock()
+	defer g.Unlock()
+	old, err := g.lookupPlayerByName(playerName)
+	if err != nil {
+		return old, err
+	}
+	return old, g.rotateSession(playerName, session)
+}
+
+// Requires game is locked!
+func (g *Game) rotateSession(playerName string, session SessionToken) error {
+	old, err := g.lookupPlayerByName(playerName)
+	if err != nil {
+		return err
+	}
+	if _, ok := g.bots[old]; ok {
+		return fmt.Errorf("that seat is played by a bot")
+	}
+	g.record(LogEntry{Type: LogRotate, Player: playerName, Session: session})
+	for i, s := range g.players {
+		if s == old {
+			g.players[i] = session
+		}
+	}
+	g.playerNames[session] = g.playerNames[old]
+	delete(g.playerNames, old)
+	if hash, ok := g.secrets[old]; ok {
+		g.secrets[session] = hash
+		delete(g.secrets, old)
+	}
+	// Anyone still waiting on the old session stops, seeing it's gone.
+	g.notifyChanged()
+	return nil
+}
 
-	return session, nil
+// Only a hash of the secret is kept, or saved.
+func hashSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
 }
@@ -2,16 +2,16 @@ package main
 
 import "testing"
 
-func serverStateWithGame() (ServerState, *Game) {
-	s := NewServer().state
-	StartGame(&s, &StartGameRequest{2, "test_game"})
+func serverStateWithGame() (*ServerState, *Game) {
+	s := &NewServer().state
+	StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "test_game"})
 	return s, s.Games["test_game"]
 }
 
 func TestJoinGame_Basic(t *testing.T) {
 	serverState, game := serverStateWithGame()
-	request := JoinGameRequest{"test_game", "player1"}
-	response := JoinGame(&serverState, &request).(*JoinGameResponse)
+	request := JoinGameRequest{GameName: "test_game", PlayerName: "player1"}
+	response := JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -21,12 +21,12 @@ func TestJoinGame_Basic(t *testing.T) {
 	if len(game.players) != 1 {
 		t.Errorf("The game should have 1 player but has %v", len(game.players))
 	}
-	response = JoinGame(&serverState, &request).(*JoinGameResponse)
+	response = JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status == "ok" {
 		t.Errorf("Expected an error when adding a duplicate player")
 	}
 	request.PlayerName = "player2"
-	response = JoinGame(&serverState, &request).(*JoinGameResponse)
+	response = JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -34,7 +34,7 @@ func TestJoinGame_Basic(t *testing.T) {
 		t.Errorf("The game should have 2 players but has %v", len(game.players))
 	}
 	request.PlayerName = "player3"
-	response = JoinGame(&serverState, &request).(*JoinGameResponse)
+	response = JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status == "ok" {
 		t.Errorf("expected to error when adding an extra player")
 	}
@@ -44,12 +44,12 @@ func TestJoinGame_Basic(t *testing.T) {
 func TestJoinGame_NumCards(t *testing.T) {
 
 	testNumCards := func(numPlayers int, numCards int) {
-		s := NewServer().state
-		StartGame(&s, &StartGameRequest{numPlayers, "test_game"})
-		request := JoinGameRequest{"test_game", "player1"}
-		response := JoinGame(&s, &request).(*JoinGameResponse)
+		s := &NewServer().state
+		StartGame(s, &StartGameRequest{NumPlayers: numPlayers, Name: "test_game"})
+		request := JoinGameRequest{GameName: "test_game", PlayerName: "player1"}
+		response := JoinGame(s, &request).(*JoinGameResponse)
 		session := response.Session
-		if hand := s.Games["test_game"].hands[session]; len(hand) != numCards {
+		if hand := s.Games["test_game"].handOf(session); len(hand) != numCards {
 			t.Errorf("expected %v cards for a %v-player game but found %v",
 				numCards, numPlayers, len(hand))
 		}
@@ -63,8 +63,8 @@ func TestJoinGame_NumCards(t *testing.T) {
 
 func TestJoinGame_WrongTypeRequest(t *testing.T) {
 	serverState, game := serverStateWithGame()
-	request := StartGameRequest{2, "test_game"}
-	response := JoinGame(&serverState, &request).(*JoinGameResponse)
+	request := StartGameRequest{NumPlayers: 2, Name: "test_game"}
+	response := JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error for wrong request type but was %v", response.Status)
 	}
@@ -75,8 +75,8 @@ func TestJoinGame_WrongTypeRequest(t *testing.T) {
 
 func TestJoinGame_BadParams(t *testing.T) {
 	serverState, game := serverStateWithGame()
-	request := JoinGameRequest{"test_game", ""}
-	response := JoinGame(&serverState, &request).(*JoinGameResponse)
+	request := JoinGameRequest{GameName: "test_game", PlayerName: ""}
+	response := JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when player has no name")
 	}
@@ -84,8 +84,8 @@ func TestJoinGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there are still no players in the game")
 	}
 
-	request = JoinGameRequest{"not_test_game", "player"}
-	response = JoinGame(&serverState, &request).(*JoinGameResponse)
+	request = JoinGameRequest{GameName: "not_test_game", PlayerName: "player"}
+	response = JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when the game doesn't exist")
 	}
@@ -93,8 +93,8 @@ func TestJoinGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there are still no players in the game")
 	}
 	request.GameName = "test_game"
-	_ = JoinGame(&serverState, &request)
-	response = JoinGame(&serverState, &request).(*JoinGameResponse)
+	_ = JoinGame(serverState, &request)
+	response = JoinGame(serverState, &request).(*JoinGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected error for joining the same player twice")
 	}
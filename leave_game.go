@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func NewLeaveGameResponseError(reason string) *LeaveGameResponse {
+	return &LeaveGameResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func LeaveGame(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*LeaveGameRequest)
+	if !ok {
+		return NewLeaveGameResponseError("cannot interpret the request as a LeaveGameRequest")
+	}
+	game := state.gameForSession(req.Session)
+	if game == nil {
+		return NewLeaveGameResponseError("Session token not found")
+	}
+	err := game.lockingLeave(req.Session)
+	if err != nil {
+		return NewLeaveGameResponseError(err.Error())
+	}
+	state.removeSession(req.Session)
+	return &LeaveGameResponse{
+		Status: "ok",
+	}
+}
+
+func (g *Game) lockingLeave(session SessionToken) error {
+	g.Lock()
+	defer g.Unlock()
+	return g.leave(session)
+}
+
+func (g *Game) lockingVacateAs(playerName string) (SessionToken, error) {
+	g.Lock()
+	defer g.Unlock()
+	return g.vacateAs(playerName)
+}
+
+// Give up the seat of a player who left, by name. Returns the session they had.
+// Requires game is locked!
+func (g *Game) vacateAs(playerName string) (SessionToken, error) {
+	session, err := g.lookupPlayerByName(playerName)
+	if err != nil {
+		return session, err
+	}
+	_, index, err := g.playerInfo(session)
+	if err != nil {
+		return session, err
+	}
+	g.vacate(session, index)
+	return session, nil
+}
+
+// Before the game starts the seat is freed and the hand goes back on the deck.
+// After that the substitute bot takes over, or the game is abandoned.
+// Requires game is locked!
+func (g *Game) leave(session SessionToken) error {
+	playerName, index, err := g.playerInfo(session)
+	if err != nil {
+		return err
+	}
+	if _, ok := g.bots[session]; ok {
+		return fmt.Errorf("bots can't leave")
+	}
+
+	switch g.overallState() {
+	case NotStarted:
+		// The event is enough to free the seat when the game is restored.
+		err = g.commit(event{Type: eventLeave, Player: playerName})
+		if err != nil {
+			return err
+		}
+	case InProgress:
+		if g.substitute == "" {
+			err = g.commit(event{Type: eventAbandon})
+			if err != nil {
+				return err
+			}
+		}
+		g.record(LogEntry{Type: LogLeave, Player: playerName})
+	default:
+		return fmt.Errorf("the game is already over")
+	}
+	g.vacate(session, index)
+	return nil
+}
+
+// Hand over the seat of a player who left, once the table shows they've gone.
+// Requires game is locked!
+func (g *Game) vacate(session SessionToken, index int) {
+	playerName := g.playerNames[session]
+	// There's no rejoining after this.
+	delete(g.secrets, session)
+	switch g.overallState() {
+	case NotStarted:
+		g.players = append(g.players[:index:index], g.players[index+1:]...)
+		delete(g.playerNames, session)
+		log.Printf("Player left %v before it started: %v", g.Name, playerName)
+	case InProgress:
+		g.addBot(g.substitute, session, index)
+		if !g.replaying {
+			// Otherwise it starts once the game is restored.
+			go g.runBot(session, g.bots[session])
+		}
+		g.notifyChanged()
+		log.Printf("Player left %v, %v bot takes over: %v", g.Name, g.substitute, playerName)
+	default:
+		log.Printf("Player left %v, abandoning it: %v", g.Name, playerName)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func leave(t *testing.T, s *ServerState, session SessionToken) *LeaveGameResponse {
+	return LeaveGame(s, &LeaveGameRequest{Session: session}).(*LeaveGameResponse)
+}
+
+func TestLeaveGame_BeforeStart(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	s := &server.Server.state
+	first := server.newTestPlayer()
+	game := s.lookupGame("test-game")
+	hand := game.handOf(first.Session)
+
+	res := leave(t, s, first.Session)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	require.Empty(t, game.players)
+	require.Len(t, game.deck, 50, "the hand goes back in the deck")
+	require.Nil(t, s.gameForSession(first.Session))
+	require.Equal(t, "error", leave(t, s, first.Session).Status)
+
+	// Whoever takes the seat gets the same cards.
+	second := server.newTestPlayer()
+	require.Equal(t, hand, game.handOf(second.Session))
+	server.newTestPlayer()
+	require.Equal(t, YourTurn, viewOf(t, game, second.Session).State)
+}
+
+func TestLeaveGame_Abandon(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	s := &server.Server.state
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+	game := s.lookupGame("test-game")
+
+	res := leave(t, s, players[1].Session)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	state := viewOf(t, game, players[0].Session)
+	require.Equal(t, Abandoned, state.State)
+	require.Equal(t, "error", leave(t, s, players[0].Session).Status, "the game is over")
+}
+
+func TestLeaveGame_Substitute(t *testing.T) {
+	s := &NewServer().state
+	res := StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "test-game", Seed: &testSeed, Substitute: "heuristic"}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	var sessions []SessionToken
+	for _, name := range []string{"a", "b"} {
+		res := JoinGame(s, &JoinGameRequest{GameName: "test-game", PlayerName: name}).(*JoinGameResponse)
+		require.Equal(t, "ok", res.Status, "%v", res.Reason)
+		sessions = append(sessions, res.Session)
+	}
+	game := s.lookupGame("test-game")
+
+	// a leaves on their own turn, and the bot moves for them.
+	require.Equal(t, "ok", leave(t, s, sessions[0]).Status)
+	state, err := getStateLoop(game, sessions[1], true, nil)
+	require.NoError(t, err)
+	require.Equal(t, YourTurn, state.State)
+	require.Equal(t, "a", state.Turns[0].Player)
+	require.Equal(t, []string{"a", "b"}, state.Players)
+
+	res = StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "bad", Substitute: "nope"}).(*StartGameResponse)
+	require.Equal(t, "error", res.Status)
+}
+
+func TestLeaveGame_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	first := server.newTestPlayer()
+	require.Equal(t, "ok", leave(t, &server.Server.state, first.Session).Status)
+	second := server.newTestPlayer()
+
+	restored := newStoredServer(t, dir)
+	game := restored.Server.state.lookupGame("test-game")
+	require.Len(t, game.players, 1)
+	require.Equal(t, server.Server.state.lookupGame("test-game").hands, game.hands)
+	require.Nil(t, restored.Server.state.gameForSession(first.Session))
+	require.NotNil(t, restored.Server.state.gameForSession(second.Session))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultListLimit = 100
+
+type ListGamesRequest struct {
+	// Optional filters.
+	State      GameState `json:"state,omitempty"` // not-started, in-progress, finished or abandoned
+	NumPlayers int       `json:"num_players,omitempty"`
+	Player     string    `json:"player,omitempty"` // only games this player has joined
+	// Paging. Games are sorted oldest first.
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"` // defaults to 100
+}
+
+type GameInfo struct {
+	Name       string    `json:"name"`
+	NumPlayers int       `json:"num_players"`
+	NumJoined  int       `json:"num_joined"`
+	Players    []string  `json:"players"`
+	State      GameState `json:"state"`
+	Score      int       `json:"score"`
+	Variant    Variant   `json:"variant"`
+	Created    time.Time `json:"created"`
+}
+
+type ListGamesResponse struct {
+	Status string     `json:"status"`
+	Reason string     `json:"reason,omitempty"`
+	Games  []GameInfo `json:"games"`
+	Total  int        `json:"total"` // how many games matched, ignoring paging
+}
+
+func NewListGamesResponseError(reason string) *ListGamesResponse {
+	return &ListGamesResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func ListGames(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*ListGamesRequest)
+	if !ok {
+		return NewListGamesResponseError("cannot interpret the request as a ListGamesRequest")
+	}
+	switch req.State {
+	case "", NotStarted, InProgress, Finished, Abandoned:
+	default:
+		return NewListGamesResponseError(fmt.Sprintf("invalid state filter: %v", req.State))
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return NewListGamesResponseError("offset and limit must not be negative")
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	matches := []GameInfo{}
+	for _, game := range state.allGames() {
+		info := game.lockingInfo()
+		if req.State != "" && info.State != req.State {
+			continue
+		}
+		if req.NumPlayers != 0 && info.NumPlayers != req.NumPlayers {
+			continue
+		}
+		if req.Player != "" && !contains(info.Players, req.Player) {
+			continue
+		}
+		matches = append(matches, info)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Created.Equal(matches[j].Created) {
+			return matches[i].Created.Before(matches[j].Created)
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	res := &ListGamesResponse{
+		Status: "ok",
+		Games:  []GameInfo{},
+		Total:  len(matches),
+	}
+	if req.Offset < len(matches) {
+		end := req.Offset + limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		res.Games = matches[req.Offset:end]
+	}
+	return res
+}
+
+// Every game. Acquires GamesMapLock.
+func (s *ServerState) allGames() []*Game {
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	games := make([]*Game, 0, len(s.Games))
+	for _, game := range s.Games {
+		games = append(games, game)
+	}
+	return games
+}
+
+func (g *Game) lockingInfo() GameInfo {
+	g.Lock()
+	defer g.Unlock()
+	info := GameInfo{
+		Name:       g.Name,
+		NumPlayers: g.NumPlayers,
+		NumJoined:  len(g.players),
+		Players:    []string{},
+		State:      g.overallState(),
+		Score:      g.Score(),
+		Variant:    g.rules.ID(),
+		Created:    g.Created,
+	}
+	for _, s := range g.players {
+		info.Players = append(info.Players, g.playerNames[s])
+	}
+	return info
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
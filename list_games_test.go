@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func serverStateWithGames() *ServerState {
+	s := &NewServer().state
+	StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "open"})
+	StartGame(s, &StartGameRequest{NumPlayers: 3, Name: "half_full"})
+	StartGame(s, &StartGameRequest{NumPlayers: 2, Name: "full"})
+	JoinGame(s, &JoinGameRequest{GameName: "half_full", PlayerName: "player1"})
+	JoinGame(s, &JoinGameRequest{GameName: "full", PlayerName: "player1"})
+	JoinGame(s, &JoinGameRequest{GameName: "full", PlayerName: "player2"})
+	// Make the order predictable.
+	for i, name := range []string{"open", "half_full", "full"} {
+		s.Games[name].Created = time.Unix(int64(i), 0)
+	}
+	return s
+}
+
+func TestListGames_All(t *testing.T) {
+	serverState := serverStateWithGames()
+	response := ListGames(serverState, &ListGamesRequest{}).(*ListGamesResponse)
+	if response.Status == "error" {
+		t.Fatalf("Expected status ok but was error: %v", response.Reason)
+	}
+	if response.Total != 3 || len(response.Games) != 3 {
+		t.Fatalf("Expected 3 games but got %v of %v", len(response.Games), response.Total)
+	}
+	if n := response.Games[0].Name; n != "open" {
+		t.Errorf("Expected the oldest game first but got %v", n)
+	}
+	half := response.Games[1]
+	if half.NumJoined != 1 || half.NumPlayers != 3 {
+		t.Errorf("Expected 1/3 players in half_full but got %v/%v", half.NumJoined, half.NumPlayers)
+	}
+	if half.State != NotStarted {
+		t.Errorf("Expected half_full is not-started but is %v", half.State)
+	}
+	full := response.Games[2]
+	if full.State != InProgress {
+		t.Errorf("Expected full is in-progress but is %v", full.State)
+	}
+	if len(full.Players) != 2 || full.Players[1] != "player2" {
+		t.Errorf("Expected full has player1 and player2 but has %v", full.Players)
+	}
+}
+
+func TestListGames_Filters(t *testing.T) {
+	serverState := serverStateWithGames()
+	response := ListGames(serverState, &ListGamesRequest{State: NotStarted}).(*ListGamesResponse)
+	if response.Total != 2 {
+		t.Errorf("Expected 2 not-started games but got %v", response.Total)
+	}
+	response = ListGames(serverState, &ListGamesRequest{NumPlayers: 3}).(*ListGamesResponse)
+	if response.Total != 1 || response.Games[0].Name != "half_full" {
+		t.Errorf("Expected only half_full has 3 players but got %v", response.Games)
+	}
+	response = ListGames(serverState, &ListGamesRequest{Player: "player2"}).(*ListGamesResponse)
+	if response.Total != 1 || response.Games[0].Name != "full" {
+		t.Errorf("Expected player2 is only in full but got %v", response.Games)
+	}
+	response = ListGames(serverState, &ListGamesRequest{State: "nonsense"}).(*ListGamesResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for an invalid state filter")
+	}
+}
+
+func TestListGames_Paging(t *testing.T) {
+	serverState := serverStateWithGames()
+	response := ListGames(serverState, &ListGamesRequest{Offset: 1, Limit: 1}).(*ListGamesResponse)
+	if response.Total != 3 {
+		t.Errorf("Expected a total of 3 games but got %v", response.Total)
+	}
+	if len(response.Games) != 1 || response.Games[0].Name != "half_full" {
+		t.Errorf("Expected the second page to be half_full but got %v", response.Games)
+	}
+	response = ListGames(serverState, &ListGamesRequest{Offset: 5}).(*ListGamesResponse)
+	if response.Status == "error" || len(response.Games) != 0 {
+		t.Errorf("Expected no games past the end but got %v", response.Games)
+	}
+}
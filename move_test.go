@@ -88,3 +88,86 @@ func TestMove_Discard(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestMove_TurnRecord(t *testing.T) {
+	server, players := setupTest(t, 2)
+	game := server.Server.state.Games["test-game"]
+
+	one := 1
+	played := game.cardsByID[one]
+	err := players[0].Move(Move{
+		Type:   Play,
+		CardID: &one,
+	})
+	require.NoError(t, err)
+
+	turn := game.turns[0]
+	require.NotNil(t, turn.Card)
+	require.Equal(t, played, *turn.Card)
+	if played.Number == 1 {
+		require.Equal(t, Success, turn.Result)
+		require.Equal(t, 3, turn.BombsLeft)
+	} else {
+		require.Equal(t, Misplay, turn.Result)
+		require.Equal(t, 2, turn.BombsLeft)
+	}
+	require.Equal(t, 8, turn.Hints)
+
+	eight := 8
+	discarded := game.cardsByID[eight]
+	err = players[1].Move(Move{
+		Type:   Discard,
+		CardID: &eight,
+	})
+	require.NoError(t, err)
+
+	turn = game.turns[1]
+	require.Equal(t, discarded, *turn.Card)
+	require.Equal(t, Discarded, turn.Result)
+}
+
+func TestMove_RainbowHint(t *testing.T) {
+	server := newTestServer(t)
+	// Player 1 holds 5:red 6:black 7:yellow 8:green 9:blue.
+	order := make([]int, 60)
+	for i := range order {
+		order[i] = i
+	}
+	for _, swap := range [][2]int{{6, 50}, {7, 10}, {8, 20}, {9, 30}} {
+		order[swap[0]], order[swap[1]] = order[swap[1]], order[swap[0]]
+	}
+	res := StartGame(&server.Server.state, &StartGameRequest{
+		NumPlayers: 2,
+		Name:       "test-game",
+		Variant:    Rainbow,
+		Order:      order,
+	}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+
+	black := Black
+	err := players[0].Move(Move{
+		Type:     Hint,
+		ToPlayer: &players[1].Name,
+		Color:    &black,
+		CardIDs:  []int{6},
+	})
+	require.Error(t, err, "rainbow can't be hinted")
+
+	red := Red
+	err = players[0].Move(Move{
+		Type:     Hint,
+		ToPlayer: &players[1].Name,
+		Color:    &red,
+		CardIDs:  []int{5},
+	})
+	require.Error(t, err, "red hint must include the rainbow card")
+
+	err = players[0].Move(Move{
+		Type:     Hint,
+		ToPlayer: &players[1].Name,
+		Color:    &red,
+		CardIDs:  []int{5, 6},
+	})
+	require.NoError(t, err)
+}
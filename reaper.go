@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// How long games are kept around. Zero keeps them forever.
+type ReapConfig struct {
+	Unfilled time.Duration // not started, since the last player joined
+	Idle     time.Duration // in progress, since the last turn
+	Finished time.Duration // finished or abandoned, since it ended
+}
+
+const reapInterval = time.Minute
+
+// Reap every reapInterval, forever.
+func (s *ServerState) runReaper(cfg ReapConfig) {
+	for now := range time.Tick(reapInterval) {
+		s.reap(now, cfg)
+	}
+}
+
+// Remove every game that's been left alone for longer than cfg allows.
+// Games still in play are abandoned first, so no one is left waiting on them.
+// Returns the names of the reaped games.
+func (s *ServerState) reap(now time.Time, cfg ReapConfig) []string {
+	reaped := make(map[*Game]bool)
+	var names []string
+	for _, game := range s.allGames() {
+		state, ttl, ok, err := game.lockingReap(now, cfg, s.store)
+		if err != nil {
+			// Keep it, rather than lose it.
+			log.Printf("Error: reaping game %v: %v", game.Name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		log.Printf("Reaped game %v (%v, untouched for over %v)", game.Name, state, ttl)
+		reaped[game] = true
+		names = append(names, game.Name)
+	}
+	s.removeGames(reaped)
+	return names
+}
+
+// If the game has outlived its TTL, archive it to store and mark it reaped.
+// Returns the state it was in, its TTL and whether it was reaped.
+// An expired game that isn't over yet is archived as abandoned, and only
+// abandoned once it's safely archived.
+func (g *Game) lockingReap(now time.Time, cfg ReapConfig, store *Storage) (GameState, time.Duration, bool, error) {
+	g.Lock()
+	defer g.Unlock()
+	state := g.overallState()
+	var ttl time.Duration
+	switch state {
+	case NotStarted:
+		ttl = cfg.Unfilled
+	case InProgress:
+		ttl = cfg.Idle
+	default:
+		ttl = cfg.Finished
+	}
+	if ttl == 0 || now.Sub(g.lastChanged) <= ttl {
+		return state, ttl, false, nil
+	}
+	end := event{Type: eventAbandon}
+	last := g.table
+	if !state.Over() {
+		var err error
+		last, err = last.apply(end)
+		if err != nil {
+			return state, ttl, false, err
+		}
+	}
+	err := store.Archive(g.dump(&last))
+	if err != nil {
+		return state, ttl, false, err
+	}
+	if !state.Over() {
+		err = g.commit(end)
+		if err != nil {
+			return state, ttl, false, err
+		}
+	}
+	g.record(LogEntry{Type: LogReap})
+	return state, ttl, true, nil
+}
+
+// Forget games and every session in them.
+func (s *ServerState) removeGames(games map[*Game]bool) {
+	if len(games) == 0 {
+		return
+	}
+	s.queueLock.Lock()
+	defer s.queueLock.Unlock()
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	for game := range games {
+		delete(s.Games, game.Name)
+	}
+	for session, game := range s.Sessions {
+		if games[game] {
+			delete(s.Sessions, session)
+		}
+	}
+	for settings, game := range s.queues {
+		if games[game] {
+			delete(s.queues, settings)
+		}
+	}
+}
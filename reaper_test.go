@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReap_Unfilled(t *testing.T) {
+	s := &NewServer().state
+	res := enqueue(t, s, EnqueueRequest{PlayerName: "a", NumPlayers: 2})
+	cfg := ReapConfig{Unfilled: time.Hour}
+	require.Empty(t, s.reap(time.Now(), cfg))
+	require.Equal(t, []string{res.GameName}, s.reap(time.Now().Add(2*time.Hour), cfg))
+	require.Empty(t, s.Games)
+	require.Empty(t, s.Sessions)
+
+	// The queue starts a new game rather than filling the reaped one.
+	next := enqueue(t, s, EnqueueRequest{PlayerName: "b", NumPlayers: 2})
+	require.NotEqual(t, res.GameName, next.GameName)
+}
+
+func TestReap_Idle(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+	s := &server.Server.state
+	game := s.lookupGame("test-game")
+
+	type waited struct {
+		state GameStateSummary
+		err   error
+	}
+	done := make(chan waited)
+	go func() {
+		state, err := getStateLoop(game, players[1].Session, true, nil)
+		done <- waited{state, err}
+	}()
+
+	later := time.Now().Add(2 * time.Hour)
+	require.Empty(t, s.reap(later, ReapConfig{Unfilled: time.Hour, Finished: time.Hour}))
+	require.Equal(t, []string{"test-game"}, s.reap(later, ReapConfig{Idle: time.Hour}))
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, Abandoned, res.state.State, "waiting players are let go")
+	require.Nil(t, s.lookupGame("test-game"))
+	require.Nil(t, s.gameForSession(players[0].Session))
+}
+
+func TestReap_Finished(t *testing.T) {
+	s := &NewServer().state
+	sessions := startTimedGame(t, s, 0.01, TimeoutAbandon)
+	state, err := getStateLoop(s.lookupGame("test-game"), sessions[1], true, nil)
+	require.NoError(t, err)
+	require.Equal(t, Abandoned, state.State)
+
+	later := time.Now().Add(time.Minute)
+	require.Empty(t, s.reap(later, ReapConfig{Idle: time.Second}), "it's over, not idle")
+	require.Equal(t, []string{"test-game"}, s.reap(later, ReapConfig{Finished: time.Second}))
+}
+
+func TestReap_Archive(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	server.newTestPlayer()
+	s := &server.Server.state
+	require.Len(t, s.reap(time.Now().Add(time.Hour), ReapConfig{Unfilled: time.Minute}), 1)
+
+	f, err := os.Open(filepath.Join(dir, archiveFileName))
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	var dump GameDump
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &dump))
+	require.Equal(t, "test-game", dump.Name)
+	require.Equal(t, Abandoned, dump.State)
+	require.Equal(t, []string{"test-player-0"}, dump.Players)
+
+	saved, err := os.ReadFile(filepath.Join(dir, logFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(saved), `"type":"abandon"`, "abandoning it is saved before it's reaped")
+
+	restored := newStoredServer(t, dir)
+	require.Empty(t, restored.Server.state.Games, "reaped games stay reaped")
+	require.Empty(t, restored.Server.state.Sessions)
+}
+
+func TestReap_ArchiveFails(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	server.newTestPlayer()
+	server.newTestPlayer()
+	s := &server.Server.state
+	s.store.archive.Close()
+
+	require.Empty(t, s.reap(time.Now().Add(time.Hour), ReapConfig{Idle: time.Minute}))
+	game := s.lookupGame("test-game")
+	require.Equal(t, InProgress, game.lockingInfo().State, "it's only abandoned once it's archived")
+
+	restored := newStoredServer(t, dir)
+	require.Equal(t, InProgress, restored.Server.state.lookupGame("test-game").lockingInfo().State)
+}
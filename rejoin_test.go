@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func joinWithSecret(s *ServerState, name string, secret string, rotate bool) *JoinGameResponse {
+	return JoinGame(s, &JoinGameRequest{GameName: "test-game", PlayerName: name, Secret: secret, RotateSession: rotate}).(*JoinGameResponse)
+}
+
+func TestJoinGame_Rejoin(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	s := &server.Server.state
+	first := joinWithSecret(s, "a", "hunter2", false)
+	require.Equal(t, "ok", first.Status, "%v", first.Reason)
+
+	again := joinWithSecret(s, "a", "hunter2", false)
+	require.Equal(t, "ok", again.Status, "%v", again.Reason)
+	require.Equal(t, first.Session, again.Session)
+
+	wrong := joinWithSecret(s, "a", "hunter3", false)
+	require.Equal(t, "error", wrong.Status)
+	noSecret := joinWithSecret(s, "a", "", false)
+	require.Equal(t, "error", noSecret.Status)
+
+	// The game fills up and starts, and a can still get back in.
+	server.newTestPlayer()
+	mid := joinWithSecret(s, "a", "hunter2", false)
+	require.Equal(t, "ok", mid.Status, "%v", mid.Reason)
+	require.Equal(t, first.Session, mid.Session)
+	require.Equal(t, YourTurn, viewOf(t, s.lookupGame("test-game"), mid.Session).State)
+}
+
+func TestJoinGame_RejoinRotate(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	s := &server.Server.state
+	first := joinWithSecret(s, "a", "hunter2", false)
+	player := server.newTestPlayer()
+	game := s.lookupGame("test-game")
+	hand := game.handOf(first.Session)
+
+	rotated := joinWithSecret(s, "a", "hunter2", true)
+	require.Equal(t, "ok", rotated.Status, "%v", rotated.Reason)
+	require.NotEqual(t, first.Session, rotated.Session)
+	require.Nil(t, s.gameForSession(first.Session), "the old session stops working")
+	require.Equal(t, game, s.gameForSession(rotated.Session))
+	require.Equal(t, hand, game.handOf(rotated.Session))
+	require.Equal(t, YourTurn, viewOf(t, game, rotated.Session).State)
+
+	restored := newStoredServer(t, dir)
+	rs := &restored.Server.state
+	require.Nil(t, rs.gameForSession(first.Session))
+	require.NotNil(t, rs.gameForSession(rotated.Session))
+	require.NotNil(t, rs.gameForSession(player.Session))
+	again := joinWithSecret(rs, "a", "hunter2", false)
+	require.Equal(t, rotated.Session, again.Session, "the secret survives a restart")
+}
+
+func TestJoinGame_RejoinAfterLeaving(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	s := &server.Server.state
+	first := joinWithSecret(s, "a", "hunter2", false)
+	require.Equal(t, "ok", leave(t, s, first.Session).Status)
+
+	// It's a fresh join now, with a new session.
+	again := joinWithSecret(s, "a", "hunter2", false)
+	require.Equal(t, "ok", again.Status, "%v", again.Reason)
+	require.NotEqual(t, first.Session, again.Session)
+}
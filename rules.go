@@ -0,0 +1,164 @@
+package main
+
+// A RuleSet is everything that differs between variants of the game.
+// Games pick one by ID in StartGameRequest.Variant.
+type RuleSet interface {
+	ID() Variant
+	// The suits in play. The board has a pile for each.
+	Colors() []Color
+	// Every card in the deck, before shuffling. IDs are assigned when dealing.
+	Deck() []Card
+	HandSize(numPlayers int) int
+	// The numbers on the cards, lowest first. Playing the last one completes a pile.
+	Numbers() []int
+	// Whether players may give a hint for this color at all.
+	CanHintColor(color Color) bool
+	CanHintNumber(number int) bool
+	// Whether a hint includes the card.
+	ColorTouches(color Color, card Card) bool
+	NumberTouches(number int, card Card) bool
+	// Hints start at the max. Completed piles give one back.
+	MaxHints() int
+	Bombs() int
+	MaxScore() int
+	// Whether the game ends right away, before the deck runs out.
+	IsOver(score int, bombsLeft int) bool
+	// How many turns are played after the last card is drawn.
+	FinalTurns(numPlayers int) int
+}
+
+var ruleSets = make(map[Variant]RuleSet)
+
+func registerRuleSet(r RuleSet) {
+	ruleSets[r.ID()] = r
+}
+
+// Can return nil.
+func lookupRuleSet(id Variant) RuleSet {
+	return ruleSets[id]
+}
+
+func init() {
+	registerRuleSet(standardRules{})
+	registerRuleSet(rainbowRules{})
+}
+
+// The standard game.
+type standardRules struct{}
+
+func (standardRules) ID() Variant {
+	return Standard
+}
+
+func (standardRules) Colors() []Color {
+	return Colors[:]
+}
+
+func (r standardRules) Deck() []Card {
+	return deckForColors(Colors[:], r.Numbers())
+}
+
+func (standardRules) HandSize(numPlayers int) int {
+	if numPlayers <= 3 {
+		return 5
+	}
+	return 4
+}
+
+func (standardRules) Numbers() []int {
+	return Numbers[:]
+}
+
+func (standardRules) CanHintColor(color Color) bool {
+	for _, c := range Colors {
+		if c == color {
+			return true
+		}
+	}
+	return false
+}
+
+func (standardRules) CanHintNumber(number int) bool {
+	for _, n := range Numbers {
+		if n == number {
+			return true
+		}
+	}
+	return false
+}
+
+func (standardRules) ColorTouches(color Color, card Card) bool {
+	return card.Color == color
+}
+
+func (standardRules) NumberTouches(number int, card Card) bool {
+	return card.Number == number
+}
+
+func (standardRules) MaxHints() int {
+	return 8
+}
+
+func (standardRules) Bombs() int {
+	return 3
+}
+
+func (r standardRules) MaxScore() int {
+	return len(Colors) * len(r.Numbers())
+}
+
+func (r standardRules) IsOver(score int, bombsLeft int) bool {
+	return bombsLeft <= 0 || score >= r.MaxScore()
+}
+
+func (standardRules) FinalTurns(numPlayers int) int {
+	return numPlayers
+}
+
+// Adds a sixth, rainbow suit (Black) that every color hint touches.
+type rainbowRules struct {
+	standardRules
+}
+
+var RainbowColors = [...]Color{Red, Yellow, Green, Blue, White, Black}
+
+func (rainbowRules) ID() Variant {
+	return Rainbow
+}
+
+func (rainbowRules) Colors() []Color {
+	return RainbowColors[:]
+}
+
+func (r rainbowRules) Deck() []Card {
+	return deckForColors(RainbowColors[:], r.Numbers())
+}
+
+func (r rainbowRules) ColorTouches(color Color, card Card) bool {
+	return card.Color == color || card.Color == Black
+}
+
+func (r rainbowRules) MaxScore() int {
+	return len(RainbowColors) * len(r.Numbers())
+}
+
+func (r rainbowRules) IsOver(score int, bombsLeft int) bool {
+	return bombsLeft <= 0 || score >= r.MaxScore()
+}
+
+// Three 1s, two each of 2-4 and one 5 per color.
+func deckForColors(colors []Color, numbers []int) []Card {
+	var cards []Card
+	for _, color := range colors {
+		for n_i, number := range numbers {
+			dupe := []int{3, 2, 2, 2, 1}[n_i]
+			for d_i := 0; d_i < dupe; d_i++ {
+				cards = append(cards, Card{
+					Color:  color,
+					Number: number,
+				})
+			}
+		}
+	}
+	return cards
+}
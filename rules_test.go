@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleSets_Deck(t *testing.T) {
+	for _, tc := range []struct {
+		id       Variant
+		numCards int
+		maxScore int
+	}{
+		{Standard, 50, 25},
+		{Rainbow, 60, 30},
+	} {
+		rules := lookupRuleSet(tc.id)
+		require.NotNil(t, rules, "%v", tc.id)
+		require.Equal(t, tc.id, rules.ID())
+		require.Len(t, rules.Deck(), tc.numCards)
+		require.Equal(t, tc.maxScore, rules.MaxScore())
+
+		counts := make(map[Card]int)
+		for _, card := range rules.Deck() {
+			counts[card]++
+		}
+		for _, color := range rules.Colors() {
+			require.Equal(t, 3, counts[Card{Color: color, Number: 1}])
+			require.Equal(t, 2, counts[Card{Color: color, Number: 4}])
+			require.Equal(t, 1, counts[Card{Color: color, Number: 5}])
+		}
+	}
+	require.Nil(t, lookupRuleSet("nonsense"))
+
+	rules := lookupRuleSet(Rainbow)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, rules.Numbers())
+	require.True(t, rules.CanHintNumber(5))
+	require.False(t, rules.CanHintNumber(0))
+	require.False(t, rules.CanHintNumber(6))
+}
+
+func TestRuleSets_EndConditions(t *testing.T) {
+	rules := lookupRuleSet(Standard)
+	require.False(t, rules.IsOver(24, 1))
+	require.True(t, rules.IsOver(25, 1))
+	require.True(t, rules.IsOver(3, 0))
+
+	rules = lookupRuleSet(Rainbow)
+	require.False(t, rules.IsOver(25, 1))
+	require.True(t, rules.IsOver(30, 1))
+}
@@ -8,26 +8,78 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"reflect"
 )
 
 const pfx = "/hanabi/"
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simulate":
+			os.Exit(simulateCommand(os.Args[2:]))
+		case "tournament":
+			os.Exit(tournamentCommand(os.Args[2:]))
+		case "export-replay":
+			os.Exit(exportReplayCommand(os.Args[2:]))
+		}
+	}
+
 	port := flag.Int("port", 9001, "port to listen on")
+	dataDir := flag.String("data-dir", "", "directory to save games in so they survive restarts (default: don't save)")
+	adminToken := flag.String("admin-token", "", "token for admin endpoints like dump-state (default: disabled)")
+	var reapCfg ReapConfig
+	flag.DurationVar(&reapCfg.Unfilled, "reap-unfilled", 0, "remove games that haven't filled up after this long (default: never)")
+	flag.DurationVar(&reapCfg.Idle, "reap-idle", 0, "abandon and remove games with no moves for this long (default: never)")
+	flag.DurationVar(&reapCfg.Finished, "reap-finished", 0, "remove games this long after they end (default: never)")
 	flag.Parse()
 	serveStr := fmt.Sprintf(":%v", *port)
-	log.Printf("Serving at localhost%v", serveStr)
 	server := NewServer()
+	server.state.AdminToken = *adminToken
+	if *dataDir != "" {
+		store, entries, err := OpenStorage(*dataDir)
+		if err != nil {
+			log.Fatalf("Error opening storage: %v", err)
+		}
+		err = server.state.restore(store, entries)
+		if err != nil {
+			log.Fatalf("Error restoring games: %v", err)
+		}
+	}
+	if reapCfg != (ReapConfig{}) {
+		go server.state.runReaper(reapCfg)
+	}
+	log.Printf("Serving at localhost%v", serveStr)
+	log.Fatal(http.ListenAndServe(serveStr, server.Routes()))
+}
 
+func (s *Server) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
 	path := "/hanabi/start-game"
-	http.HandleFunc(path, server.MakeHandler(path, StartGame, &StartGameRequest{}))
+	mux.HandleFunc(path, s.MakeHandler(path, StartGame, &StartGameRequest{}))
 	path = "/hanabi/join-game"
-	http.HandleFunc(path, server.MakeHandler(path, JoinGame, &JoinGameRequest{}))
+	mux.HandleFunc(path, s.MakeHandler(path, JoinGame, &JoinGameRequest{}))
+	path = "/hanabi/enqueue"
+	mux.HandleFunc(path, s.MakeHandler(path, Enqueue, &EnqueueRequest{}))
+	path = "/hanabi/leave-game"
+	mux.HandleFunc(path, s.MakeHandler(path, LeaveGame, &LeaveGameRequest{}))
+	path = "/hanabi/spectate"
+	mux.HandleFunc(path, s.MakeHandler(path, Spectate, &SpectateRequest{}))
 	path = "/hanabi/get-state"
-	http.HandleFunc(path, server.MakeHandler(path, GetState, &GetStateRequest{}))
+	mux.HandleFunc(path, s.MakeHandler(path, GetState, &GetStateRequest{}))
 	path = "/hanabi/move"
-	http.HandleFunc(path, server.MakeHandler(path, MoveHandler, &MoveRequest{}))
-	log.Fatal(http.ListenAndServe(serveStr, nil))
+	mux.HandleFunc(path, s.MakeHandler(path, MoveHandler, &MoveRequest{}))
+	path = "/hanabi/list-games"
+	mux.HandleFunc(path, s.MakeHandler(path, ListGames, &ListGamesRequest{}))
+	path = "/hanabi/export-replay"
+	mux.HandleFunc(path, s.MakeHandler(path, ExportReplay, &ExportReplayRequest{}))
+	path = "/hanabi/fork-game"
+	mux.HandleFunc(path, s.MakeHandler(path, ForkGame, &ForkGameRequest{}))
+	path = "/hanabi/dump-state"
+	mux.HandleFunc(path, s.MakeHandler(path, DumpState, &DumpStateRequest{}))
+	mux.HandleFunc("/hanabi/ws", s.WatchHandler)
+	return mux
 }
 
 type Server struct {
@@ -38,6 +90,11 @@ type ServerState struct {
 	Games        map[string]*Game
 	Sessions     map[SessionToken]*Game
 	GamesMapLock sync.Mutex // Lock that guards the mappings, not the Games.
+	store        *Storage   // nil if games aren't saved
+	AdminToken   string     // empty disables admin endpoints
+
+	queueLock sync.Mutex              // Serializes enqueue requests
+	queues    map[matchSettings]*Game // The game each queue is filling
 }
 
 // Get a game. Acquires GamesMapLock. Can return nil.
@@ -61,11 +118,25 @@ func (s *ServerState) addSession(session SessionToken, game *Game) {
 	s.Sessions[session] = game
 }
 
+func (s *ServerState) removeSession(session SessionToken) {
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	delete(s.Sessions, session)
+}
+
+func (s *ServerState) replaceSession(old SessionToken, new SessionToken) {
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	s.Sessions[new] = s.Sessions[old]
+	delete(s.Sessions, old)
+}
+
 func NewServer() *Server {
 	return &Server{
 		state: ServerState{
 			Games:    make(map[string]*Game),
 			Sessions: make(map[SessionToken]*Game),
+			queues:   make(map[matchSettings]*Game),
 		},
 	}
 }
@@ -81,13 +152,15 @@ func (s *Server) MakeHandler(path string, f func(*ServerState, interface{}) inte
 		dec := json.NewDecoder(req.Body)
 		var response interface{}
 		var err error
-		err = dec.Decode(&requestStruct)
+		// Decode into a fresh request every time, requests run concurrently.
+		request := reflect.New(reflect.TypeOf(requestStruct).Elem()).Interface()
+		err = dec.Decode(request)
 		if err != nil {
 			err = fmt.Errorf("error decoding request: %v", err)
 			handleErr(err, w)
 			return
 		}
-		response = f(&s.state, requestStruct)
+		response = f(&s.state, request)
 		writeJson(w, response)
 	}
 }
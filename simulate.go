@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Player chooses moves from what its seat can see.
+// It's only asked for a move on its own turn.
+type Player interface {
+	ChooseMove(state *GameStateSummary) Move
+}
+
+type SimulationResult struct {
+	Seed      int64 `json:"seed"`
+	Score     int   `json:"score"`
+	Turns     int   `json:"turns"`
+	BombsLeft int   `json:"bombs_left"`
+}
+
+// Play a whole game between players, seated in order, with no server in between.
+// Returns an error if a player makes an illegal move.
+func Simulate(players []Player, variant Variant, seed int64) (SimulationResult, error) {
+	res := SimulationResult{Seed: seed}
+	rules := lookupRuleSet(variant)
+	if rules == nil {
+		return res, fmt.Errorf("unknown variant: %v", variant)
+	}
+	if len(players) < 2 || len(players) > 5 {
+		return res, fmt.Errorf("must have 2-5 players")
+	}
+	_, order, _ := dealOrder(&StartGameRequest{Seed: &seed}, rules)
+	g := newGame("simulation", len(players), rules, &seed, order)
+	for i := range players {
+		_, err := g.lockingJoinGame(fmt.Sprintf("player-%v", i+1), "")
+		if err != nil {
+			return res, err
+		}
+	}
+
+	for g.whoseTurn != -1 {
+		session := g.players[g.whoseTurn]
+		state, err := g.getState(session, 0)
+		if err != nil {
+			return res, err
+		}
+		move := players[g.whoseTurn].ChooseMove(&state)
+		err = g.lockingMove(session, move)
+		if err != nil {
+			return res, fmt.Errorf("%v made an illegal move on turn %v: %v", state.WhoseTurn, len(g.turns), err)
+		}
+	}
+	res.Score = g.Score()
+	res.Turns = len(g.turns)
+	res.BombsLeft = g.bombs
+	return res, nil
+}
+
+// Play numGames games with seeds firstSeed, firstSeed+1, ..., using every CPU.
+// newPlayers is called once per game, since Players may keep state between turns.
+func SimulateGames(numGames int, firstSeed int64, variant Variant, newPlayers func(seed int64) ([]Player, error)) ([]SimulationResult, error) {
+	results := make([]SimulationResult, numGames)
+	errs := make([]error, numGames)
+	parallel(numGames, func(i int) {
+		seed := firstSeed + int64(i)
+		players, err := newPlayers(seed)
+		if err == nil {
+			results[i], err = Simulate(players, variant, seed)
+		}
+		errs[i] = err
+	})
+	for i, err := range errs {
+		if err != nil {
+			return results, fmt.Errorf("game with seed %v: %v", firstSeed+int64(i), err)
+		}
+	}
+	return results, nil
+}
+
+// Call f(0), ..., f(n-1), one per CPU at a time.
+func parallel(n int, f func(i int)) {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// Mean and the half-width of its 95% confidence interval.
+func meanAndCI(scores []int) (mean float64, ci float64) {
+	n := float64(len(scores))
+	if n == 0 {
+		return 0, 0
+	}
+	for _, s := range scores {
+		mean += float64(s)
+	}
+	mean /= n
+	if n < 2 {
+		return mean, 0
+	}
+	var sumSq float64
+	for _, s := range scores {
+		sumSq += (float64(s) - mean) * (float64(s) - mean)
+	}
+	stddev := math.Sqrt(sumSq / (n - 1))
+	return mean, 1.96 * stddev / math.Sqrt(n)
+}
+
+// $ hanabi-server simulate -players heuristic,heuristic -games 1000
+func simulateCommand(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	playersFlag := flags.String("players", "heuristic,heuristic", "comma-separated built-in bots, one per seat")
+	numGames := flags.Int("games", 1000, "number of games to play")
+	firstSeed := flags.Int64("seed", 0, "seed of the first game; the rest count up from it")
+	variant := flags.String("variant", string(Standard), "rule set to play with")
+	flags.Parse(args)
+
+	strategies := strings.Split(*playersFlag, ",")
+	for _, strategy := range strategies {
+		err := checkBotStrategy(strategy)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	newPlayers := func(seed int64) ([]Player, error) {
+		var players []Player
+		for i, strategy := range strategies {
+			p, err := NewBot(strategy, seed+int64(i))
+			if err != nil {
+				return nil, err
+			}
+			players = append(players, p)
+		}
+		return players, nil
+	}
+
+	start := time.Now()
+	results, err := SimulateGames(*numGames, *firstSeed, Variant(*variant), newPlayers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	elapsed := time.Since(start)
+
+	scores := make([]int, len(results))
+	perfect := 0
+	bombedOut := 0
+	maxScore := lookupRuleSet(Variant(*variant)).MaxScore()
+	for i, r := range results {
+		scores[i] = r.Score
+		if r.Score == maxScore {
+			perfect++
+		}
+		if r.BombsLeft == 0 {
+			bombedOut++
+		}
+	}
+	mean, ci := meanAndCI(scores)
+	fmt.Printf("players:    %v\n", strings.Join(strategies, ", "))
+	fmt.Printf("games:      %v in %v\n", len(results), elapsed.Round(time.Millisecond))
+	fmt.Printf("mean score: %.2f ± %.2f\n", mean, ci)
+	fmt.Printf("perfect:    %v\n", perfect)
+	fmt.Printf("bombed out: %v\n", bombedOut)
+	return 0
+}
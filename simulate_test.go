@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Always plays its first card.
+type firstCardPlayer struct{}
+
+func (firstCardPlayer) ChooseMove(state *GameStateSummary) Move {
+	return playMove(state.Hand[0].ID)
+}
+
+// Always hints the wrong cards.
+type badHintPlayer struct{}
+
+func (badHintPlayer) ChooseMove(state *GameStateSummary) Move {
+	red := Red
+	to := state.Players[0]
+	return Move{Type: Hint, ToPlayer: &to, Color: &red, CardIDs: []int{-1}}
+}
+
+func TestSimulate_Basic(t *testing.T) {
+	res, err := Simulate([]Player{firstCardPlayer{}, firstCardPlayer{}}, Standard, 7)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), res.Seed)
+	require.Greater(t, res.Turns, 0)
+	require.Equal(t, 0, res.BombsLeft, "playing blind should bomb out")
+
+	again, err := Simulate([]Player{firstCardPlayer{}, firstCardPlayer{}}, Standard, 7)
+	require.NoError(t, err)
+	require.Equal(t, res, again, "the same seed should play out the same way")
+}
+
+func TestSimulate_IllegalMove(t *testing.T) {
+	_, err := Simulate([]Player{firstCardPlayer{}, badHintPlayer{}}, Standard, 7)
+	require.Error(t, err)
+
+	_, err = Simulate([]Player{firstCardPlayer{}}, Standard, 7)
+	require.Error(t, err)
+}
+
+func TestSimulateGames(t *testing.T) {
+	newPlayers := func(seed int64) ([]Player, error) {
+		a, _ := NewBot("heuristic", seed)
+		b, _ := NewBot("random", seed)
+		return []Player{a, b}, nil
+	}
+	results, err := SimulateGames(50, 100, Rainbow, newPlayers)
+	require.NoError(t, err)
+	require.Len(t, results, 50)
+	for i, r := range results {
+		require.Equal(t, int64(100+i), r.Seed)
+	}
+}
+
+func TestMeanAndCI(t *testing.T) {
+	mean, ci := meanAndCI([]int{10, 20})
+	require.InDelta(t, 15, mean, 1e-9)
+	require.InDelta(t, 1.96*7.0710678/1.4142136, ci, 1e-4)
+
+	mean, ci = meanAndCI(nil)
+	require.Equal(t, 0.0, mean)
+	require.Equal(t, 0.0, ci)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+func NewSpectateResponseError(reason string) *SpectateResponse {
+	return &SpectateResponse{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+func Spectate(state *ServerState, req_ interface{}) interface{} {
+	req, ok := req_.(*SpectateRequest)
+	if !ok {
+		return NewSpectateResponseError("cannot interpret the request as a SpectateRequest")
+	}
+	if req.GameName == "" {
+		return NewSpectateResponseError("missing required field \"game_name\"")
+	}
+	game := state.lookupGame(req.GameName)
+	if game == nil {
+		return NewSpectateResponseError("no game found with that name")
+	}
+	if req.AsPlayer == "" && !game.lockingInfo().State.Over() && !state.checkAdminToken(req.AdminToken) {
+		return NewSpectateResponseError("not authorized to see every hand of a game in progress")
+	}
+	session, err := RandomSessionToken()
+	if err != nil {
+		return NewSpectateResponseError("error generating session token")
+	}
+	err = game.lockingSpectateAs(session, req.AsPlayer)
+	if err != nil {
+		return NewSpectateResponseError(err.Error())
+	}
+	state.addSession(session, game)
+	if req.AsPlayer != "" {
+		log.Printf("Spectating %v as %v", req.GameName, req.AsPlayer)
+	} else {
+		log.Printf("Spectating %v", req.GameName)
+	}
+	return &SpectateResponse{
+		Status:  "ok",
+		Session: session,
+	}
+}
+
+// Who a spectator sees the game as.
+type spectator struct {
+	asPlayer string // the player whose view they get, unless seesAll
+	seesAll  bool   // every hand shown and nothing redacted
+}
+
+// asPlayer is who the spectator sees the game as, or empty to see every hand.
+func (g *Game) lockingSpectateAs(session SessionToken, asPlayer string) error {
+	g.Lock()
+	defer g.Unlock()
+	if asPlayer != "" {
+		_, err := g.lookupPlayerByName(asPlayer)
+		if err != nil {
+			return fmt.Errorf("no player with that name has joined")
+		}
+	}
+	g.record(LogEntry{Type: LogSpectate, Session: session, AsPlayer: asPlayer})
+	if g.spectators == nil {
+		g.spectators = make(map[SessionToken]spectator)
+	}
+	g.spectators[session] = spectator{asPlayer: asPlayer, seesAll: asPlayer == ""}
+	return nil
+}
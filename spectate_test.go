@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func spectate(t *testing.T, s *ServerState, asPlayer string) SessionToken {
+	res := Spectate(s, &SpectateRequest{GameName: "test-game", AsPlayer: asPlayer}).(*SpectateResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	return res.Session
+}
+
+func TestSpectate_SeeAll(t *testing.T) {
+	server, players := setupTest(t, 2)
+	s := &server.Server.state
+	s.AdminToken = "secret"
+	res := Spectate(s, &SpectateRequest{GameName: "test-game", AdminToken: "secret"}).(*SpectateResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	session := res.Session
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Discard, CardID: &one}))
+
+	state, err := getStateLoop(s.gameForSession(session), session, false, nil)
+	require.NoError(t, err)
+	require.Equal(t, WaitingForTurn, state.State)
+	require.Equal(t, players[1].Name, state.WhoseTurn)
+	require.Empty(t, state.Hand)
+	require.Len(t, state.OtherHands, 2, "every hand is shown")
+	require.IsType(t, &Card{}, state.Turns[0].NewCard, "nothing is redacted")
+
+	moved := MoveHandler(s, &MoveRequest{Session: session, Move: discardMove(one)}).(*MoveResponse)
+	require.Equal(t, "error", moved.Status)
+	require.Contains(t, moved.Reason, "spectators")
+}
+
+func TestSpectate_SeeAllNeedsAdmin(t *testing.T) {
+	server, players := setupTest(t, 2)
+	s := &server.Server.state
+	s.AdminToken = "secret"
+	for _, token := range []string{"", "wrong"} {
+		res := Spectate(s, &SpectateRequest{GameName: "test-game", AdminToken: token}).(*SpectateResponse)
+		require.Equal(t, "error", res.Status, "a player could read their own hand")
+		require.Contains(t, res.Reason, "not authorized")
+	}
+	spectate(t, s, players[0].Name)
+
+	// Anyone can see every hand once it's over.
+	require.Equal(t, "ok", leave(t, s, players[0].Session).Status)
+	spectate(t, s, "")
+}
+
+func TestSpectate_AsPlayer(t *testing.T) {
+	server, players := setupTest(t, 2)
+	s := &server.Server.state
+	session := spectate(t, s, players[0].Name)
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Discard, CardID: &one}))
+
+	game := s.gameForSession(session)
+	state := viewOf(t, game, session)
+	seen := viewOf(t, game, players[0].Session)
+	require.Equal(t, seen.Hand, state.Hand)
+	require.Equal(t, seen.OtherHands, state.OtherHands)
+	require.Equal(t, seen.Turns, state.Turns, "the player's own draws stay hidden")
+
+	// A spectator never has a turn, even when the player they see as does.
+	require.NoError(t, players[1].Move(Move{Type: Discard, CardID: &seen.OtherHands[players[1].Name][0].ID}))
+	require.Equal(t, YourTurn, viewOf(t, game, players[0].Session).State)
+	require.Equal(t, WaitingForTurn, viewOf(t, game, session).State)
+
+	res := Spectate(s, &SpectateRequest{GameName: "test-game", AsPlayer: "nobody"}).(*SpectateResponse)
+	require.Equal(t, "error", res.Status)
+}
+
+func TestSpectate_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	server.newTestPlayer()
+	session := spectate(t, &server.Server.state, "test-player-0")
+
+	restored := newStoredServer(t, dir)
+	game := restored.Server.state.gameForSession(session)
+	require.NotNil(t, game)
+	require.Equal(t, spectator{asPlayer: "test-player-0"}, game.spectators[session])
+}
+
+func TestSpectate_PlayerLeft(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	player := server.newTestPlayer()
+	s := &server.Server.state
+	session := spectate(t, s, player.Name)
+	game := s.lookupGame("test-game")
+
+	require.Equal(t, "ok", leave(t, s, player.Session).Status)
+	_, err := game.getState(session, 0)
+	require.Error(t, err, "they don't get to see every hand instead")
+	_, err = getStateLoop(game, session, false, nil)
+	require.Error(t, err)
+
+	// Even once someone else takes the seat.
+	server.newTestPlayer()
+	_, err = game.getState(session, 0)
+	require.Error(t, err)
+}
@@ -1,84 +1,236 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"math/rand"
+	"time"
 )
 
-type StartGameRequest struct {
-	NumPlayers int    `json:"num_players"`
-	Name       string `json:"name"`
-}
-
-type StartGameResponse struct {
-	Status string `json:"status"`
-	Reason string `json:"reason,omitempty"`
+func NewStartGameResponseError(reason string) *StartGameResponse {
+	return &StartGameResponse{
+		Status: "error",
+		Reason: reason,
+	}
 }
 
 func StartGame(state *ServerState, req_ interface{}) interface{} {
 	req, ok := req_.(*StartGameRequest)
 	if !ok {
-		return &StartGameResponse{"error", "cannot interpret the request as a StartGameRequest"}
+		return NewStartGameResponseError("cannot interpret the request as a StartGameRequest")
 	}
 	state.GamesMapLock.Lock()
 	defer state.GamesMapLock.Unlock()
+	game, err := state.createGame(req, nil)
+	if err != nil {
+		return NewStartGameResponseError(err.Error())
+	}
+	for i, strategy := range req.Bots {
+		err = game.lockingJoinBot(strategy, botName(strategy, i))
+		if err != nil {
+			// The game is already saved, so seat whoever we can.
+			log.Printf("Error: seating %v in game %v: %v", strategy, req.Name, err)
+		}
+	}
+	game.lockingStartBots()
+	if game.Seed != nil {
+		log.Printf("Started game: %v (seed %v)", req.Name, *game.Seed)
+	} else {
+		log.Printf("Started game: %v (fixed order)", req.Name)
+	}
+	return &StartGameResponse{Status: "ok"}
+}
+
+// Check the request, then add and save the game. Bots aren't seated yet.
+// match is the queue that started it, if any, so it can be queued again on restore.
+// Requires GamesMapLock!
+func (s *ServerState) createGame(req *StartGameRequest, match *matchSettings) (*Game, error) {
+	if req.NumPlayers == 0 && req.Replay != nil {
+		// A copy, so the caller's request isn't changed.
+		withPlayers := *req
+		withPlayers.NumPlayers = len(req.Replay.Players)
+		req = &withPlayers
+	}
 	if req.Name == "" {
-		return &StartGameResponse{"error", "missing required field \"name\""}
+		return nil, fmt.Errorf("missing required field \"name\"")
 	}
-	if _, ok := state.Games[req.Name]; ok {
-		return &StartGameResponse{"error", "game with the same name exists"}
+	if _, ok := s.Games[req.Name]; ok {
+		return nil, fmt.Errorf("game with the same name exists")
 	}
 	if req.NumPlayers == 0 {
-		return &StartGameResponse{"error", "missing required field \"num_players\""}
+		return nil, fmt.Errorf("missing required field \"num_players\"")
 	}
 	if req.NumPlayers < 2 || req.NumPlayers > 5 {
-		return &StartGameResponse{"error", "must specify 2-5 players"}
+		return nil, fmt.Errorf("must specify 2-5 players")
+	}
+	if len(req.Bots) > req.NumPlayers {
+		return nil, fmt.Errorf("too many bots for %v players", req.NumPlayers)
+	}
+	for _, strategy := range req.Bots {
+		err := checkBotStrategy(strategy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	variant := req.Variant
+	if req.Replay != nil {
+		v, err := replayVariant(req.Replay)
+		if err != nil {
+			return nil, err
+		}
+		if variant != "" && variant != v {
+			return nil, fmt.Errorf("the replay is for the %v variant, not %v", v, variant)
+		}
+		variant = v
+	}
+	if variant == "" {
+		variant = Standard
+	}
+	rules := lookupRuleSet(variant)
+	if rules == nil {
+		return nil, fmt.Errorf("unknown variant: %v", variant)
+	}
+	if req.TurnTimeLimit < 0 {
+		return nil, fmt.Errorf("turn_time_limit must not be negative")
+	}
+	timeoutAction := req.TimeoutAction
+	switch timeoutAction {
+	case "":
+		timeoutAction = TimeoutDiscard
+	case TimeoutDiscard, TimeoutAbandon:
+	default:
+		return nil, fmt.Errorf("invalid timeout_action: %v", timeoutAction)
+	}
+	if req.Substitute != "" {
+		err := checkBotStrategy(req.Substitute)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.FastForward < 0 {
+		return nil, fmt.Errorf("fast_forward must not be negative")
+	}
+	if req.FastForward > 0 && req.Replay == nil {
+		return nil, fmt.Errorf("fast_forward needs a replay")
+	}
+	seed, order, err := dealOrder(req, rules)
+	if err != nil {
+		return nil, err
 	}
-	deck, cardsByID := newDeck()
-	newGame := &Game{
-		Name:        req.Name,
+	game := newGame(req.Name, req.NumPlayers, rules, seed, order)
+	game.store = s.store
+	game.turnTimeLimit = time.Duration(req.TurnTimeLimit * float64(time.Second))
+	game.timeoutAction = timeoutAction
+	game.substitute = req.Substitute
+	if req.FastForward > 0 {
+		game.fastForward, err = checkFastForward(req, rules, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.Games[req.Name] = game
+	// Save what was dealt, not just what was asked for.
+	// Bots are saved when they join.
+	// Fast-forwarded moves are saved like any others.
+	saved := *req
+	saved.Seed = seed
+	saved.Variant = variant
+	saved.Bots = nil
+	if seed == nil {
+		saved.Order = order
+		saved.Deck = nil
+		saved.Replay = nil
+		saved.FastForward = 0
+	}
+	game.record(LogEntry{Type: LogStart, Start: &saved, Match: match})
+	return game, nil
+}
+
+func newGame(name string, numPlayers int, rules RuleSet, seed *int64, order []int) *Game {
+	deck, cardsByID := newDeck(rules, order)
+	deal := newTable(rules, numPlayers, deck)
+	now := time.Now()
+	return &Game{
+		Name:        name,
+		Seed:        seed,
+		Created:     now,
+		NumPlayers:  numPlayers,
+		cardsByID:   cardsByID,
+		deal:        deal,
+		table:       deal,
 		players:     nil,
 		playerNames: make(map[SessionToken]string),
-		NumPlayers:  req.NumPlayers,
-		turns:       make([]Turn, 0),
-		deck:        deck,
-		hands:       make(map[SessionToken][]Card, req.NumPlayers),
-		board: map[Color][]Card{
-			White:  nil,
-			Blue:   nil,
-			Red:    nil,
-			Green:  nil,
-			Yellow: nil},
-		bombs:     3,
-		hints:     8,
-		discard:   make([]Card, 0),
-		cardsByID: cardsByID,
-		whoseTurn: 0,
-	}
-	state.Games[req.Name] = newGame
-	log.Printf("Started game: %v", req.Name)
-	return &StartGameResponse{"ok", ""}
+		changed:     make(chan struct{}),
+		lastChanged: now,
+	}
+}
+
+// The order to deal the deck in, and the seed it came from if it wasn't given explicitly.
+func dealOrder(req *StartGameRequest, rules RuleSet) (*int64, []int, error) {
+	numCards := len(rules.Deck())
+	given := 0
+	for _, ok := range []bool{req.Seed != nil, req.Order != nil, req.Deck != nil, req.Replay != nil} {
+		if ok {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, nil, fmt.Errorf("specify at most one of \"seed\", \"order\", \"deck\" and \"replay\"")
+	}
+	if req.Replay != nil {
+		deck, err := replayDeck(req.Replay, rules)
+		if err != nil {
+			return nil, nil, err
+		}
+		order, err := orderForDeck(deck, rules)
+		return nil, order, err
+	}
+	if req.Deck != nil {
+		order, err := orderForDeck(req.Deck, rules)
+		return nil, order, err
+	}
+	if req.Order != nil {
+		err := checkOrder(req.Order, numCards)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, req.Order, nil
+	}
+	seed := req.Seed
+	if seed == nil {
+		s, err := RandomSeed()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generating seed")
+		}
+		seed = &s
+	}
+	return seed, rand.New(rand.NewSource(*seed)).Perm(numCards), nil
 }
 
-func newDeck() (Deck, map[int]Card) {
-	numCards := 5 * (3 + 2 + 2 + 2 + 1)
+func checkOrder(order []int, numCards int) error {
+	if len(order) != numCards {
+		return fmt.Errorf("order must have %v cards but has %v", numCards, len(order))
+	}
+	seen := make(map[int]bool, numCards)
+	for _, i := range order {
+		if i < 0 || i >= numCards || seen[i] {
+			return fmt.Errorf("order must be a permutation of 0-%v", numCards-1)
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+// Shuffle the rule set's deck so that card p goes to position order[p].
+// A card's ID is its position in the deck.
+func newDeck(rules RuleSet, order []int) (Deck, map[int]Card) {
+	numCards := len(order)
 	cards := make([]Card, numCards)
 	cardsByID := make(map[int]Card, numCards)
-	order := rand.Perm(numCards)
-	p_i := 0
-	for _, color := range Colors {
-		for n_i, number := range Numbers {
-			dupe := []int{3, 2, 2, 2, 1}[n_i]
-			for d_i := 0; d_i < dupe; d_i++ {
-				cards[order[p_i]] = Card{
-					ID:     order[p_i],
-					Color:  color,
-					Number: number,
-				}
-				cardsByID[order[p_i]] = cards[order[p_i]]
-				p_i++
-			}
-		}
+	for p_i, card := range rules.Deck() {
+		card.ID = order[p_i]
+		cards[card.ID] = card
+		cardsByID[card.ID] = card
 	}
 	return Deck(cards), cardsByID
 }
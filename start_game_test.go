@@ -3,9 +3,9 @@ package main
 import "testing"
 
 func TestStartGame_Basic(t *testing.T) {
-	serverState := NewServer().state
-	request := StartGameRequest{2, "test_game"}
-	response := StartGame(&serverState, &request).(*StartGameResponse)
+	serverState := &NewServer().state
+	request := StartGameRequest{NumPlayers: 2, Name: "test_game"}
+	response := StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected status ok but was error: %v", response.Reason)
 	}
@@ -38,9 +38,9 @@ func TestStartGame_Basic(t *testing.T) {
 }
 
 func TestStartGame_WrongTypeRequest(t *testing.T) {
-	serverState := NewServer().state
-	request := JoinGameRequest{"test_game", "test_player"}
-	response := StartGame(&serverState, &request).(*StartGameResponse)
+	serverState := &NewServer().state
+	request := JoinGameRequest{GameName: "test_game", PlayerName: "test_player"}
+	response := StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error for wrong request type but was %v", response.Status)
 	}
@@ -50,9 +50,9 @@ func TestStartGame_WrongTypeRequest(t *testing.T) {
 }
 
 func TestStartGame_BadParams(t *testing.T) {
-	serverState := NewServer().state
-	request := StartGameRequest{0, "test_game"}
-	response := StartGame(&serverState, &request).(*StartGameResponse)
+	serverState := &NewServer().state
+	request := StartGameRequest{NumPlayers: 0, Name: "test_game"}
+	response := StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when NumPlayers is 0")
 	}
@@ -60,8 +60,8 @@ func TestStartGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there are still no games in the server state")
 	}
 
-	request = StartGameRequest{1, "test_game"}
-	response = StartGame(&serverState, &request).(*StartGameResponse)
+	request = StartGameRequest{NumPlayers: 1, Name: "test_game"}
+	response = StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when NumPlayers is 1")
 	}
@@ -69,8 +69,8 @@ func TestStartGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there are still no games in the server state")
 	}
 
-	request = StartGameRequest{6, "test_game"}
-	response = StartGame(&serverState, &request).(*StartGameResponse)
+	request = StartGameRequest{NumPlayers: 6, Name: "test_game"}
+	response = StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when NumPlayers is 6")
 	}
@@ -78,8 +78,8 @@ func TestStartGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there are still no games in the server state")
 	}
 
-	request = StartGameRequest{5, ""}
-	response = StartGame(&serverState, &request).(*StartGameResponse)
+	request = StartGameRequest{NumPlayers: 5, Name: ""}
+	response = StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when Name is empty")
 	}
@@ -88,8 +88,8 @@ func TestStartGame_BadParams(t *testing.T) {
 	}
 
 	// Valid game
-	request = StartGameRequest{5, "test_game"}
-	response = StartGame(&serverState, &request).(*StartGameResponse)
+	request = StartGameRequest{NumPlayers: 5, Name: "test_game"}
+	response = StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status == "error" {
 		t.Errorf("Expected %v to succeed", request)
 	}
@@ -97,8 +97,8 @@ func TestStartGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there is now one game in the server state")
 	}
 
-	request = StartGameRequest{3, "test_game"}
-	response = StartGame(&serverState, &request).(*StartGameResponse)
+	request = StartGameRequest{NumPlayers: 3, Name: "test_game"}
+	response = StartGame(serverState, &request).(*StartGameResponse)
 	if response.Status != "error" {
 		t.Errorf("Expected State=error when adding a duplicate game")
 	}
@@ -106,3 +106,87 @@ func TestStartGame_BadParams(t *testing.T) {
 		t.Errorf("Expected that there is still only one game in the server state")
 	}
 }
+
+func TestStartGame_Seed(t *testing.T) {
+	serverState := &NewServer().state
+	seed := int64(42)
+	StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "game1", Seed: &seed})
+	StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "game2", Seed: &seed})
+	game1, game2 := serverState.Games["game1"], serverState.Games["game2"]
+	if game1.Seed == nil || *game1.Seed != seed {
+		t.Errorf("Expected the game to record seed %v but has %v", seed, game1.Seed)
+	}
+	for i := range game1.deck {
+		if game1.deck[i] != game2.deck[i] {
+			t.Fatalf("Expected the same seed to deal the same deck but card %v is %v and %v",
+				i, game1.deck[i], game2.deck[i])
+		}
+	}
+
+	StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "game3"})
+	if game3 := serverState.Games["game3"]; game3.Seed == nil {
+		t.Errorf("Expected a random seed to be recorded")
+	}
+}
+
+func TestStartGame_Order(t *testing.T) {
+	serverState := &NewServer().state
+	order := make([]int, 50)
+	for i := range order {
+		order[i] = 49 - i
+	}
+	response := StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "test_game", Order: order}).(*StartGameResponse)
+	if response.Status == "error" {
+		t.Fatalf("Expected status ok but was error: %v", response.Reason)
+	}
+	game := serverState.Games["test_game"]
+	if game.Seed != nil {
+		t.Errorf("Expected no seed for an explicit order but got %v", *game.Seed)
+	}
+	// The first cards in the order are the red 1s.
+	if c := game.deck[49]; c.Color != Red || c.Number != 1 {
+		t.Errorf("Expected the last card is a red 1 but is %v", c)
+	}
+	if c := game.deck[0]; c.Color != White || c.Number != 5 {
+		t.Errorf("Expected the first card is a white 5 but is %v", c)
+	}
+
+	order[0] = 0
+	response = StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "bad_order", Order: order}).(*StartGameResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for an order that isn't a permutation")
+	}
+	seed := int64(1)
+	response = StartGame(serverState, &StartGameRequest{NumPlayers: 2, Name: "both", Order: order[:10], Seed: &seed}).(*StartGameResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected an error for both a seed and an order")
+	}
+}
+
+func TestStartGame_Rainbow(t *testing.T) {
+	serverState := &NewServer().state
+	request := StartGameRequest{NumPlayers: 2, Name: "test_game", Variant: Rainbow}
+	response := StartGame(serverState, &request).(*StartGameResponse)
+	if response.Status == "error" {
+		t.Fatalf("Expected status ok but was error: %v", response.Reason)
+	}
+	game := serverState.Games["test_game"]
+	if len(game.deck) != 60 {
+		t.Errorf("The deck should have 60 cards but has %v", len(game.deck))
+	}
+	if len(game.board) != 6 {
+		t.Errorf("The board should have 6 piles but has %v", len(game.board))
+	}
+	if _, ok := game.board[Black]; !ok {
+		t.Errorf("The board should have a pile for rainbow (black) cards")
+	}
+	if game.rules.MaxScore() != 30 {
+		t.Errorf("The max score should be 30 but is %v", game.rules.MaxScore())
+	}
+
+	request = StartGameRequest{NumPlayers: 2, Name: "other_game", Variant: "nonsense"}
+	response = StartGame(serverState, &request).(*StartGameResponse)
+	if response.Status != "error" {
+		t.Errorf("Expected State=error for an unknown variant")
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Storage is an append-only log of every game's start and events, along with
+// the sessions that hold each seat, so that the games can be rebuilt after the server restarts.
+// Reaped games are archived to a second log, one GameDump per line.
+// A nil *Storage saves nothing.
+type Storage struct {
+	sync.Mutex
+	file    *os.File
+	archive *os.File
+}
+
+type LogEntryType string
+
+const (
+	LogStart    LogEntryType = "start"
+	LogEvent    LogEntryType = "event"  // anything that changed the table, see events.go
+	LogReap     LogEntryType = "reap"   // the game was removed, and archived first if it was reaped
+	LogLeave    LogEntryType = "leave"  // during the game, so the seat is handed over
+	LogRotate   LogEntryType = "rotate" // the player rejoined with a new session
+	LogSpectate LogEntryType = "spectate"
+)
+
+type LogEntry struct {
+	Type LogEntryType `json:"type"`
+	Time time.Time    `json:"time"`
+	Game string       `json:"game"`
+	// for Start:
+	Start *StartGameRequest `json:"start,omitempty"`
+	Match *matchSettings    `json:"match,omitempty"` // the queue that started it, for matchmaking games
+	// for Event:
+	Event *event `json:"event,omitempty"`
+	// for Leave:
+	Player string `json:"player,omitempty"`
+	// for Spectate, who they see as:
+	AsPlayer string `json:"as_player,omitempty"`
+	// for join Events/Rotate/Spectate:
+	Session SessionToken `json:"session,omitempty"`
+	// for join Events:
+	Bot        string `json:"bot,omitempty"`         // strategy, if a built-in bot took the seat
+	SecretHash string `json:"secret_hash,omitempty"` // never the secret itself
+}
+
+const logFileName = "games.log"
+const archiveFileName = "archive.log"
+
+// Open the log in dir, creating it if needed, and read back what's already there.
+func OpenStorage(dir string) (*Storage, []LogEntry, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []LogEntry
+	var end int64 // just past the last good entry
+	dec := json.NewDecoder(file)
+	for {
+		var entry LogEntry
+		err = dec.Decode(&entry)
+		if err == io.EOF {
+			_, err = file.Seek(0, io.SeekEnd)
+			break
+		}
+		if err != nil {
+			// Probably a crash in the middle of a write. Drop the partial entry so we can keep appending.
+			log.Printf("Error: reading %v after %v entries, dropping the rest: %v", logFileName, len(entries), err)
+			err = file.Truncate(end)
+			if err == nil {
+				_, err = file.Seek(end, io.SeekStart)
+			}
+			break
+		}
+		entries = append(entries, entry)
+		end = dec.InputOffset()
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	archive, err := os.OpenFile(filepath.Join(dir, archiveFileName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return &Storage{file: file, archive: archive}, entries, nil
+}
+
+// Write an entry and wait for it to hit the disk.
+func (s *Storage) Append(entry LogEntry) error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return writeLine(s.file, entry)
+}
+
+// Keep everything about a game before it's forgotten.
+func (s *Storage) Archive(dump *GameDump) error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	return writeLine(s.archive, dump)
+}
+
+func writeLine(f *os.File, v interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(bs, '\n'))
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (s *Storage) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.archive.Close()
+	return s.file.Close()
+}
+
+// Save an entry for this game.
+// Failing to save doesn't stop the game, so errors are only logged.
+func (g *Game) record(entry LogEntry) {
+	entry.Game = g.Name
+	err := g.store.Append(entry)
+	if err != nil {
+		log.Printf("Error: saving %v for game %v: %v", entry.Type, g.Name, err)
+	}
+}
+
+// Apply a saved event, and seat or unseat whoever it was about.
+// Returns the session of a player who left.
+func (g *Game) lockingRestore(entry LogEntry) (SessionToken, error) {
+	g.Lock()
+	defer g.Unlock()
+	e := *entry.Event
+	err := g.commit(e)
+	if err != nil {
+		return "", err
+	}
+	switch e.Type {
+	case eventJoin:
+		g.seat(e.Player, entry.Session, entry.Bot, entry.SecretHash)
+	case eventLeave:
+		return g.vacateAs(e.Player)
+	}
+	return "", nil
+}
+
+// Rebuild the games from the log, then save everything that happens from now on to store.
+func (s *ServerState) restore(store *Storage, entries []LogEntry) error {
+	for i, entry := range entries {
+		err := s.replay(entry)
+		if err != nil {
+			return fmt.Errorf("replaying entry %v (%v %v): %v", i, entry.Type, entry.Game, err)
+		}
+		if game := s.lookupGame(entry.Game); game != nil {
+			// So the reaper goes by when things happened, not when they were replayed.
+			game.Lock()
+			game.lastChanged = entry.Time
+			game.Unlock()
+		}
+	}
+
+	s.queueLock.Lock()
+	for settings, game := range s.queues {
+		if game.lockingInfo().State != NotStarted {
+			delete(s.queues, settings)
+		}
+	}
+	s.queueLock.Unlock()
+
+	s.GamesMapLock.Lock()
+	defer s.GamesMapLock.Unlock()
+	s.store = store
+	for _, game := range s.Games {
+		game.Lock()
+		game.store = store
+		game.replaying = false
+		game.startTurnTimer()
+		game.Unlock()
+		// Bots only start moving once every saved move has been replayed.
+		game.lockingStartBots()
+	}
+	log.Printf("Restored %v games and %v sessions", len(s.Games), len(s.Sessions))
+	return nil
+}
+
+func (s *ServerState) replay(entry LogEntry) error {
+	switch entry.Type {
+	case LogStart:
+		if entry.Start == nil {
+			return fmt.Errorf("missing start request")
+		}
+		res := StartGame(s, entry.Start).(*StartGameResponse)
+		if res.Status != "ok" {
+			return fmt.Errorf("%v", res.Reason)
+		}
+		game := s.lookupGame(entry.Game)
+		game.Lock()
+		game.Created = entry.Time
+		game.replaying = true
+		game.Unlock()
+		if entry.Match != nil {
+			// The queue's newest game. It's dropped again if it fills up.
+			s.queueLock.Lock()
+			s.queues[*entry.Match] = game
+			s.queueLock.Unlock()
+		}
+		return nil
+	case LogEvent:
+		game := s.lookupGame(entry.Game)
+		if game == nil {
+			return fmt.Errorf("no game found with that name")
+		}
+		if entry.Event == nil {
+			return fmt.Errorf("missing event")
+		}
+		if entry.Bot != "" {
+			err := checkBotStrategy(entry.Bot)
+			if err != nil {
+				return err
+			}
+		}
+		left, err := game.lockingRestore(entry)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.Event.Type == eventJoin && entry.Bot == "":
+			s.addSession(entry.Session, game)
+		case entry.Event.Type == eventLeave:
+			s.removeSession(left)
+		}
+		return nil
+	case LogLeave:
+		game := s.lookupGame(entry.Game)
+		if game == nil {
+			return fmt.Errorf("no game found with that name")
+		}
+		session, err := game.lockingVacateAs(entry.Player)
+		if err != nil {
+			return err
+		}
+		s.removeSession(session)
+		return nil
+	case LogRotate:
+		game := s.lookupGame(entry.Game)
+		if game == nil {
+			return fmt.Errorf("no game found with that name")
+		}
+		old, err := game.lockingRotateSession(entry.Player, entry.Session)
+		if err != nil {
+			return err
+		}
+		s.replaceSession(old, entry.Session)
+		return nil
+	case LogSpectate:
+		game := s.lookupGame(entry.Game)
+		if game == nil {
+			return fmt.Errorf("no game found with that name")
+		}
+		err := game.lockingSpectateAs(entry.Session, entry.AsPlayer)
+		if err != nil {
+			return err
+		}
+		s.addSession(entry.Session, game)
+		return nil
+	case LogReap:
+		game := s.lookupGame(entry.Game)
+		if game == nil {
+			return fmt.Errorf("no game found with that name")
+		}
+		s.removeGames(map[*Game]bool{game: true})
+		return nil
+	default:
+		return fmt.Errorf("unrecognized entry type: %v", entry.Type)
+	}
+}
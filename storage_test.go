@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newStoredServer(t *testing.T, dir string) *testServer {
+	store, entries, err := OpenStorage(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	server := newTestServer(t)
+	require.NoError(t, server.Server.state.restore(store, entries))
+	return server
+}
+
+func TestStorage_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	players := []*testPlayer{server.newTestPlayer(), server.newTestPlayer()}
+	red := Red
+	require.NoError(t, players[0].Move(Move{
+		Type:     Hint,
+		ToPlayer: &players[1].Name,
+		Color:    &red,
+		CardIDs:  []int{5, 8},
+	}))
+	eight := 8
+	require.NoError(t, players[1].Move(Move{Type: Play, CardID: &eight}))
+	before := server.Server.state.Games["test-game"]
+
+	restored := newStoredServer(t, dir)
+	after := restored.Server.state.lookupGame("test-game")
+	require.NotNil(t, after)
+	require.Equal(t, before.Seed, after.Seed)
+	require.Equal(t, before.players, after.players)
+	require.Equal(t, before.hands, after.hands)
+	require.Equal(t, before.deck, after.deck)
+	require.Equal(t, before.board, after.board)
+	require.Equal(t, before.hints, after.hints)
+	require.Equal(t, before.whoseTurn, after.whoseTurn)
+	require.Len(t, after.turns, 2)
+	require.Equal(t, before.events, after.events)
+	require.Equal(t, before.table, after.table)
+
+	// The old sessions still work, and new moves are saved too.
+	players[0].Server = restored
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Discard, CardID: &one}))
+	again := newStoredServer(t, dir)
+	require.Len(t, again.Server.state.lookupGame("test-game").turns, 3)
+}
+
+func TestStorage_PartialEntry(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	server.StartGame()
+	server.newTestPlayer()
+
+	// Crash halfway through writing an entry.
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"type":"event","game":"test-ga`)
+	require.NoError(t, err)
+	f.Close()
+
+	restored := newStoredServer(t, dir)
+	require.Len(t, restored.Server.state.lookupGame("test-game").players, 1)
+	restored.Players = server.Players
+	restored.newTestPlayer()
+
+	again := newStoredServer(t, dir)
+	require.Len(t, again.Server.state.lookupGame("test-game").players, 2)
+}
@@ -23,10 +23,14 @@ func newTestServer(t *testing.T) *testServer {
 	}
 }
 
+// Deals cards 5 and 8 as player 1's only red cards.
+var testSeed int64 = 86
+
 func (s *testServer) StartGame() {
 	req := StartGameRequest{
 		NumPlayers: 2,
 		Name:       "test-game",
+		Seed:       &testSeed,
 	}
 	res := StartGame(&s.Server.state, &req).(*StartGameResponse)
 	require.NotNil(s.T, res)
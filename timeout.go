@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Start the clock on the current turn, or stop it if the game isn't in progress.
+// Requires game is locked!
+func (g *Game) startTurnTimer() {
+	if g.turnTimer != nil {
+		g.turnTimer.Stop()
+		g.turnTimer = nil
+		g.turnEnds = time.Time{}
+	}
+	if g.turnTimeLimit == 0 || g.replaying || g.overallState() != InProgress {
+		return
+	}
+	turn := len(g.turns)
+	g.turnEnds = time.Now().Add(g.turnTimeLimit)
+	g.turnTimer = time.AfterFunc(g.turnTimeLimit, func() {
+		g.lockingTimeout(turn)
+	})
+}
+
+// Time out turn, unless it's already been played.
+func (g *Game) lockingTimeout(turn int) {
+	g.Lock()
+	defer g.Unlock()
+	if len(g.turns) != turn || g.overallState() != InProgress {
+		return
+	}
+	player := g.playerNames[g.players[g.whoseTurn]]
+	log.Printf("Player %v in game %v ran out of time, %v", player, g.Name, g.timeoutAction)
+	err := g.timeout()
+	if err != nil {
+		log.Printf("Error: timing out %v in game %v: %v", player, g.Name, err)
+	}
+}
+
+// The current player ran out of time.
+// Requires game is locked!
+func (g *Game) timeout() error {
+	if g.overallState() != InProgress {
+		return fmt.Errorf("no one can time out, the game isn't in progress")
+	}
+	player := g.names[g.whoseTurn]
+	return g.commit(event{Type: eventTimeout, Player: player, Action: g.timeoutAction})
+}
+
+// Seconds left in the current turn, nil if the clock isn't running.
+// Requires game is locked!
+func (g *Game) turnTimeLeft() *float64 {
+	if g.turnTimer == nil {
+		return nil
+	}
+	left := time.Until(g.turnEnds).Seconds()
+	if left < 0 {
+		left = 0
+	}
+	return &left
+}
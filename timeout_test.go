@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startTimedGame(t *testing.T, s *ServerState, limit float64, action TimeoutAction) []SessionToken {
+	res := StartGame(s, &StartGameRequest{
+		NumPlayers:    2,
+		Name:          "test-game",
+		Seed:          &testSeed,
+		TurnTimeLimit: limit,
+		TimeoutAction: action,
+	}).(*StartGameResponse)
+	require.Equal(t, "ok", res.Status, "%v", res.Reason)
+	var sessions []SessionToken
+	for _, name := range []string{"a", "b"} {
+		res := JoinGame(s, &JoinGameRequest{GameName: "test-game", PlayerName: name}).(*JoinGameResponse)
+		require.Equal(t, "ok", res.Status, "%v", res.Reason)
+		sessions = append(sessions, res.Session)
+	}
+	return sessions
+}
+
+func TestTimeout_Discard(t *testing.T) {
+	s := &NewServer().state
+	sessions := startTimedGame(t, s, 0.05, "")
+	game := s.lookupGame("test-game")
+	before := viewOf(t, game, sessions[0])
+	require.NotNil(t, before.TurnTimeLeft)
+	require.LessOrEqual(t, *before.TurnTimeLeft, 0.05)
+
+	// a never moves, so the server discards for them and it's b's turn.
+	state, err := getStateLoop(game, sessions[1], true, nil)
+	require.NoError(t, err)
+	require.Equal(t, YourTurn, state.State)
+	require.Len(t, state.Turns, 1)
+	turn := state.Turns[0]
+	require.True(t, turn.Timeout)
+	require.Equal(t, "a", turn.Player)
+	require.Equal(t, Discard, turn.Move.Type)
+	require.Equal(t, before.Hand[0].ID, *turn.Move.CardID, "the oldest card goes")
+	require.NotNil(t, state.TurnTimeLeft, "b's clock is running")
+
+	// Moving in time resets the clock.
+	one := before.Hand[1].ID
+	require.NoError(t, game.lockingMove(sessions[1], discardMove(state.Hand[0].ID)))
+	require.NoError(t, game.lockingMove(sessions[0], discardMove(one)))
+	require.False(t, viewOf(t, game, sessions[0]).Turns[2].Timeout)
+}
+
+func TestTimeout_Abandon(t *testing.T) {
+	s := &NewServer().state
+	sessions := startTimedGame(t, s, 0.05, TimeoutAbandon)
+	game := s.lookupGame("test-game")
+
+	state, err := getStateLoop(game, sessions[1], true, nil)
+	require.NoError(t, err)
+	require.Equal(t, Abandoned, state.State)
+	require.Nil(t, state.TurnTimeLeft)
+	require.Len(t, state.Turns, 1)
+	require.True(t, state.Turns[0].Timeout)
+	require.Equal(t, MoveType(""), state.Turns[0].Move.Type)
+	require.Error(t, game.lockingMove(sessions[0], discardMove(state.Hand[0].ID)))
+
+	res := ListGames(s, &ListGamesRequest{State: Abandoned}).(*ListGamesResponse)
+	require.Equal(t, 1, res.Total)
+}
+
+func TestTimeout_NoLimit(t *testing.T) {
+	s := &NewServer().state
+	sessions := startTimedGame(t, s, 0, "")
+	require.Nil(t, viewOf(t, s.lookupGame("test-game"), sessions[0]).TurnTimeLeft)
+
+	for _, req := range []StartGameRequest{
+		{NumPlayers: 2, Name: "negative", TurnTimeLimit: -1},
+		{NumPlayers: 2, Name: "action", TurnTimeLimit: 1, TimeoutAction: "explode"},
+	} {
+		res := StartGame(s, &req).(*StartGameResponse)
+		require.Equal(t, "error", res.Status, "%+v", req)
+	}
+}
+
+func TestTimeout_Restore(t *testing.T) {
+	dir := t.TempDir()
+	server := newStoredServer(t, dir)
+	sessions := startTimedGame(t, &server.Server.state, 0.05, "")
+	game := server.Server.state.lookupGame("test-game")
+	_, err := getStateLoop(game, sessions[1], true, nil)
+	require.NoError(t, err)
+	game.Lock()
+	// Stop the clock so b doesn't time out too.
+	game.turnTimeLimit = 0
+	game.startTurnTimer()
+	game.Unlock()
+
+	restored := newStoredServer(t, dir)
+	after := restored.Server.state.lookupGame("test-game")
+	after.Lock()
+	defer after.Unlock()
+	require.Len(t, after.turns, 1)
+	require.True(t, after.turns[0].Timeout)
+	require.Equal(t, game.turns[0], after.turns[0])
+	require.NotNil(t, after.turnTimer, "the clock runs again after a restart")
+	after.turnTimer.Stop()
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bot entered in a tournament: either built in or reached over HTTP.
+type TournamentBot struct {
+	Name     string
+	Strategy string // a built-in bot, or
+	URL      string // where to POST the GameStateSummary, the response is the Move
+}
+
+// Parses "strategy", "name=strategy" or "name=http://host/path".
+func ParseTournamentBot(spec string) (TournamentBot, error) {
+	name, target := spec, spec
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, target = spec[:i], spec[i+1:]
+	}
+	if name == "" || target == "" {
+		return TournamentBot{}, fmt.Errorf("invalid bot: %q", spec)
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return TournamentBot{Name: name, URL: target}, nil
+	}
+	err := checkBotStrategy(target)
+	if err != nil {
+		return TournamentBot{}, err
+	}
+	return TournamentBot{Name: name, Strategy: target}, nil
+}
+
+func (b TournamentBot) newPlayer(seed int64) (Player, error) {
+	if b.URL != "" {
+		return &httpBot{url: b.URL, client: &http.Client{Timeout: httpBotTimeout}}, nil
+	}
+	return NewBot(b.Strategy, seed)
+}
+
+const httpBotTimeout = 10 * time.Second
+
+// A Player that asks a server for every move.
+type httpBot struct {
+	url    string
+	client *http.Client
+}
+
+// Returns an empty (illegal) move if the bot can't be reached.
+func (b *httpBot) ChooseMove(state *GameStateSummary) Move {
+	var move Move
+	body, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("Error encoding state for %v: %v", b.url, err)
+		return move
+	}
+	res, err := b.client.Post(b.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error asking %v for a move: %v", b.url, err)
+		return move
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.Printf("Error asking %v for a move: %v", b.url, res.Status)
+		return move
+	}
+	err = json.NewDecoder(res.Body).Decode(&move)
+	if err != nil {
+		log.Printf("Error decoding move from %v: %v", b.url, err)
+	}
+	return move
+}
+
+// Every group of NumPlayers bots plays Games games in every seating, always
+// on the seeds FirstSeed, FirstSeed+1, ..., so all groups get the same deals.
+// Games are played in standard rules.
+type Tournament struct {
+	Bots       []TournamentBot
+	NumPlayers int
+	Games      int // per seating
+	FirstSeed  int64
+}
+
+// How one group of bots did together. Failed games, where a bot made an
+// illegal move or couldn't be reached, count as a score of 0.
+type PairingResult struct {
+	Bots   []string `json:"bots"`
+	Games  int      `json:"games"`
+	Failed int      `json:"failed"`
+	Mean   float64  `json:"mean"`
+	CI     float64  `json:"ci"` // half-width of the 95% confidence interval
+}
+
+// How every game a bot sat in went.
+type BotResult struct {
+	Name  string  `json:"name"`
+	Games int     `json:"games"`
+	Mean  float64 `json:"mean"`
+	CI    float64 `json:"ci"`
+}
+
+type TournamentResult struct {
+	Pairings []PairingResult `json:"pairings"`
+	Bots     []BotResult     `json:"bots"` // best first
+}
+
+// One game in the schedule.
+type tournamentGame struct {
+	pairing int
+	seating []int // indexes into Tournament.Bots, in seat order
+	seed    int64
+}
+
+func (t *Tournament) check() error {
+	if t.NumPlayers < 2 || t.NumPlayers > 5 {
+		return fmt.Errorf("must have 2-5 players")
+	}
+	if len(t.Bots) == 0 {
+		return fmt.Errorf("no bots")
+	}
+	if t.Games < 1 {
+		return fmt.Errorf("must play at least one game per seating")
+	}
+	names := make(map[string]bool)
+	for _, b := range t.Bots {
+		if names[b.Name] {
+			return fmt.Errorf("duplicate bot name: %v", b.Name)
+		}
+		names[b.Name] = true
+		if b.URL == "" {
+			err := checkBotStrategy(b.Strategy)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Every pairing, with every bot able to take more than one seat, and every
+// distinct rotation of it around the table.
+func (t *Tournament) schedule() (pairings [][]int, games []tournamentGame) {
+	pairings = combinationsWithRepetition(len(t.Bots), t.NumPlayers)
+	for p, pairing := range pairings {
+		for _, seating := range distinctRotations(pairing) {
+			for i := 0; i < t.Games; i++ {
+				games = append(games, tournamentGame{pairing: p, seating: seating, seed: t.FirstSeed + int64(i)})
+			}
+		}
+	}
+	return pairings, games
+}
+
+func (t *Tournament) Run() (*TournamentResult, error) {
+	err := t.check()
+	if err != nil {
+		return nil, err
+	}
+	pairings, games := t.schedule()
+	scores := make([]int, len(games))
+	failed := make([]bool, len(games))
+	parallel(len(games), func(i int) {
+		game := games[i]
+		var players []Player
+		for seat, b := range game.seating {
+			p, err := t.Bots[b].newPlayer(game.seed + int64(seat))
+			if err != nil {
+				log.Printf("Error creating bot %v: %v", t.Bots[b].Name, err)
+				failed[i] = true
+				return
+			}
+			players = append(players, p)
+		}
+		res, err := Simulate(players, Standard, game.seed)
+		if err != nil {
+			log.Printf("Game with seed %v failed: %v", game.seed, err)
+			failed[i] = true
+			return
+		}
+		scores[i] = res.Score
+	})
+
+	pairingScores := make([][]int, len(pairings))
+	pairingFailed := make([]int, len(pairings))
+	botScores := make([][]int, len(t.Bots))
+	for i, game := range games {
+		pairingScores[game.pairing] = append(pairingScores[game.pairing], scores[i])
+		if failed[i] {
+			pairingFailed[game.pairing]++
+		}
+		// A bot in two seats still only played the game once.
+		seen := make(map[int]bool)
+		for _, b := range game.seating {
+			if !seen[b] {
+				botScores[b] = append(botScores[b], scores[i])
+				seen[b] = true
+			}
+		}
+	}
+
+	res := &TournamentResult{}
+	for p, pairing := range pairings {
+		pr := PairingResult{Games: len(pairingScores[p]), Failed: pairingFailed[p]}
+		for _, b := range pairing {
+			pr.Bots = append(pr.Bots, t.Bots[b].Name)
+		}
+		pr.Mean, pr.CI = meanAndCI(pairingScores[p])
+		res.Pairings = append(res.Pairings, pr)
+	}
+	for b, bot := range t.Bots {
+		br := BotResult{Name: bot.Name, Games: len(botScores[b])}
+		br.Mean, br.CI = meanAndCI(botScores[b])
+		res.Bots = append(res.Bots, br)
+	}
+	sort.SliceStable(res.Pairings, func(i, j int) bool { return res.Pairings[i].Mean > res.Pairings[j].Mean })
+	sort.SliceStable(res.Bots, func(i, j int) bool { return res.Bots[i].Mean > res.Bots[j].Mean })
+	return res, nil
+}
+
+// Every sorted k-tuple of 0..n-1, like {0,0}, {0,1}, {1,1} for n=2, k=2.
+func combinationsWithRepetition(n, k int) [][]int {
+	var res [][]int
+	var rec func(start int, prefix []int)
+	rec = func(start int, prefix []int) {
+		if len(prefix) == k {
+			res = append(res, append([]int{}, prefix...))
+			return
+		}
+		for i := start; i < n; i++ {
+			rec(i, append(prefix, i))
+		}
+	}
+	rec(0, nil)
+	return res
+}
+
+// Each way of rotating seats around the table, without repeats,
+// so {0,0} has one seating and {0,1} has two.
+func distinctRotations(seats []int) [][]int {
+	var res [][]int
+	seen := make(map[string]bool)
+	for r := range seats {
+		rotated := append(append([]int{}, seats[r:]...), seats[:r]...)
+		key := fmt.Sprint(rotated)
+		if !seen[key] {
+			seen[key] = true
+			res = append(res, rotated)
+		}
+	}
+	return res
+}
+
+// $ hanabi-server tournament -bots heuristic,random,mine=http://localhost:8000/move -games 100
+func tournamentCommand(args []string) int {
+	flags := flag.NewFlagSet("tournament", flag.ExitOnError)
+	botsFlag := flags.String("bots", "heuristic,random", "comma-separated bots: a built-in bot, name=built-in or name=http://url")
+	numPlayers := flags.Int("players", 2, "number of players in each game")
+	numGames := flags.Int("games", 100, "games per seating")
+	firstSeed := flags.Int64("seed", 0, "seed of the first game; the rest count up from it")
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	flags.Parse(args)
+
+	t := &Tournament{NumPlayers: *numPlayers, Games: *numGames, FirstSeed: *firstSeed}
+	for _, spec := range strings.Split(*botsFlag, ",") {
+		bot, err := ParseTournamentBot(spec)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		t.Bots = append(t.Bots, bot)
+	}
+
+	start := time.Now()
+	res, err := t.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+		return 0
+	}
+	fmt.Printf("finished in %v\n\n", time.Since(start).Round(time.Millisecond))
+	fmt.Println("pairings:")
+	for _, p := range res.Pairings {
+		fmt.Printf("  %-40v %6.2f ± %.2f  (%v games, %v failed)\n", strings.Join(p.Bots, ", "), p.Mean, p.CI, p.Games, p.Failed)
+	}
+	fmt.Println("\nbots:")
+	for _, b := range res.Bots {
+		fmt.Printf("  %-40v %6.2f ± %.2f  (%v games)\n", b.Name, b.Mean, b.CI, b.Games)
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTournamentBot(t *testing.T) {
+	bot, err := ParseTournamentBot("heuristic")
+	require.NoError(t, err)
+	require.Equal(t, TournamentBot{Name: "heuristic", Strategy: "heuristic"}, bot)
+
+	bot, err = ParseTournamentBot("mine=http://localhost:8000/move")
+	require.NoError(t, err)
+	require.Equal(t, TournamentBot{Name: "mine", URL: "http://localhost:8000/move"}, bot)
+
+	_, err = ParseTournamentBot("nope")
+	require.Error(t, err)
+	_, err = ParseTournamentBot("=heuristic")
+	require.Error(t, err)
+}
+
+func TestTournament_Schedule(t *testing.T) {
+	require.Equal(t, [][]int{{0, 0}, {0, 1}, {1, 1}}, combinationsWithRepetition(2, 2))
+	require.Len(t, combinationsWithRepetition(3, 3), 10)
+	require.Equal(t, [][]int{{0, 0, 1}, {0, 1, 0}, {1, 0, 0}}, distinctRotations([]int{0, 0, 1}))
+	require.Equal(t, [][]int{{2, 2}}, distinctRotations([]int{2, 2}))
+}
+
+func TestTournament_Run(t *testing.T) {
+	tour := &Tournament{
+		Bots:       []TournamentBot{{Name: "h", Strategy: "heuristic"}, {Name: "r", Strategy: "random"}},
+		NumPlayers: 2,
+		Games:      5,
+		FirstSeed:  10,
+	}
+	res, err := tour.Run()
+	require.NoError(t, err)
+	require.Len(t, res.Pairings, 3)
+	games := map[string]int{}
+	for _, p := range res.Pairings {
+		games[p.Bots[0]+p.Bots[1]] = p.Games
+		require.Equal(t, 0, p.Failed)
+	}
+	require.Equal(t, map[string]int{"hh": 5, "hr": 10, "rr": 5}, games)
+	require.Equal(t, "h", res.Bots[0].Name, "heuristic should beat random")
+	require.Equal(t, 15, res.Bots[0].Games)
+
+	again, err := tour.Run()
+	require.NoError(t, err)
+	require.Equal(t, res, again, "fixed seeds should give the same results")
+
+	tour.Bots = append(tour.Bots, TournamentBot{Name: "h", Strategy: "random"})
+	_, err = tour.Run()
+	require.Error(t, err, "duplicate name")
+}
+
+func TestTournament_HTTPBot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var state GameStateSummary
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&state))
+		json.NewEncoder(w).Encode(heuristicBot{}.ChooseMove(&state))
+	}))
+	defer ts.Close()
+
+	tour := &Tournament{
+		Bots:       []TournamentBot{{Name: "remote", URL: ts.URL}, {Name: "local", Strategy: "heuristic"}},
+		NumPlayers: 2,
+		Games:      2,
+	}
+	res, err := tour.Run()
+	require.NoError(t, err)
+	for _, p := range res.Pairings {
+		require.Equal(t, 0, p.Failed)
+	}
+	require.InDelta(t, res.Bots[0].Mean, res.Bots[1].Mean, 1e-9, "the remote bot plays the same as the local one")
+
+	ts.Close()
+	res, err = tour.Run()
+	require.NoError(t, err)
+	for _, p := range res.Pairings {
+		if p.Bots[0] == "remote" || p.Bots[1] == "remote" {
+			require.Equal(t, p.Games, p.Failed)
+			require.Equal(t, 0.0, p.Mean)
+		}
+	}
+}
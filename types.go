@@ -0,0 +1,71 @@
+package main
+
+import "github.com/seveneightn9ne/hanabi-server/client"
+
+// Everything that goes over the wire is defined in the client package, so the
+// server and the bots that use it can't disagree about it.
+
+type (
+	MoveType         = client.MoveType
+	Color            = client.Color
+	GameState        = client.GameState
+	Variant          = client.Variant
+	Move             = client.Move
+	Card             = client.Card
+	HiddenCard       = client.HiddenCard
+	Cardy            = client.Cardy
+	TurnResult       = client.TurnResult
+	Turn             = client.Turn
+	GameStateSummary = client.GameStateSummary
+	SessionToken     = client.SessionToken
+	TimeoutAction    = client.TimeoutAction
+	Replay           = client.Replay
+	ReplayCard       = client.ReplayCard
+	ReplayAction     = client.ReplayAction
+	ReplayOptions    = client.ReplayOptions
+
+	StartGameRequest  = client.StartGameRequest
+	StartGameResponse = client.StartGameResponse
+	JoinGameRequest   = client.JoinGameRequest
+	JoinGameResponse  = client.JoinGameResponse
+	EnqueueRequest    = client.EnqueueRequest
+	EnqueueResponse   = client.EnqueueResponse
+	LeaveGameRequest  = client.LeaveGameRequest
+	LeaveGameResponse = client.LeaveGameResponse
+	SpectateRequest   = client.SpectateRequest
+	SpectateResponse  = client.SpectateResponse
+	GetStateRequest   = client.GetStateRequest
+	GetStateResponse  = client.GetStateResponse
+	MoveRequest       = client.MoveRequest
+	MoveResponse      = client.MoveResponse
+)
+
+const (
+	Hint    = client.Hint
+	Play    = client.Play
+	Discard = client.Discard
+
+	Red    = client.Red
+	Yellow = client.Yellow
+	Green  = client.Green
+	Blue   = client.Blue
+	Black  = client.Black
+	White  = client.White
+
+	NotStarted     = client.NotStarted
+	WaitingForTurn = client.WaitingForTurn
+	YourTurn       = client.YourTurn
+	Finished       = client.Finished
+	Abandoned      = client.Abandoned
+	InProgress     = client.InProgress
+
+	Standard = client.Standard
+	Rainbow  = client.Rainbow
+
+	Success   = client.Success
+	Misplay   = client.Misplay
+	Discarded = client.Discarded
+
+	TimeoutDiscard = client.TimeoutDiscard
+	TimeoutAbandon = client.TimeoutAbandon
+)
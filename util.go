@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 )
 
@@ -18,3 +19,12 @@ func RandBytes(length int) ([]byte, error) {
 	}
 	return buf, nil
 }
+
+// A random non-negative seed for math/rand.
+func RandomSeed() (int64, error) {
+	bs, err := RandBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(bs) >> 1), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Clients open a websocket to /hanabi/ws and send a WatchRequest.
+// The server answers with the current state and then pushes a WatchMessage
+// every time the game changes, until the game is finished.
+
+type WatchRequest struct {
+	Session SessionToken `json:"session"`
+	// Skip the turns up to here, e.g. when reconnecting.
+	TurnCursor int `json:"turn_cursor"`
+}
+
+type WatchMessage struct {
+	Status string           `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+	Turn   *Turn            `json:"turn,omitempty"` // the turn that was just committed, if any
+	State  GameStateSummary `json:"state"`          // Turns holds the turns since the last message
+}
+
+func NewWatchMessageError(reason string) *WatchMessage {
+	return &WatchMessage{
+		Status: "error",
+		Reason: reason,
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	// Bots don't run in browsers, so there is no origin to check.
+	CheckOrigin: func(req *http.Request) bool { return true },
+}
+
+func (s *Server) WatchHandler(w http.ResponseWriter, req *http.Request) {
+	log.Printf("Request to %v", req.URL.Path)
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// Upgrade has already replied with an error.
+		log.Printf("Error: websocket upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var watchReq WatchRequest
+	err = conn.ReadJSON(&watchReq)
+	if err != nil {
+		conn.WriteJSON(NewWatchMessageError(fmt.Sprintf("error decoding request: %v", err)))
+		return
+	}
+	game := s.state.gameForSession(watchReq.Session)
+	if game == nil {
+		conn.WriteJSON(NewWatchMessageError("Session token not found"))
+		return
+	}
+	if watchReq.TurnCursor < 0 {
+		conn.WriteJSON(NewWatchMessageError("turn_cursor must not be negative"))
+		return
+	}
+
+	// Clients don't send anything else, but reading is how we notice them leave.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = watchGame(game, watchReq.Session, watchReq.TurnCursor, closed, conn.WriteJSON)
+	if err != nil {
+		log.Printf("Error: websocket write: %v", err)
+	}
+}
+
+// Send the state now and after every change, until the game is finished or done is closed.
+// A session that can no longer see the game, like one that's been rotated away, is sent an error.
+func watchGame(g *Game, session SessionToken, cursor int, done <-chan struct{}, send func(interface{}) error) error {
+	for {
+		changed := g.lockingChanged()
+		res, err := g.getState(session, cursor)
+		if err != nil {
+			return send(NewWatchMessageError(err.Error()))
+		}
+
+		if len(res.Turns) == 0 {
+			// Something other than a turn changed, like a player joining.
+			err := send(&WatchMessage{Status: "ok", State: res})
+			if err != nil {
+				return err
+			}
+		}
+		for i := range res.Turns {
+			err := send(&WatchMessage{Status: "ok", Turn: &res.Turns[i], State: res})
+			if err != nil {
+				return err
+			}
+		}
+		cursor = res.TurnCursor
+
+		if res.State.Over() {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-done:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func dialWatch(t *testing.T, server *testServer, session SessionToken) *websocket.Conn {
+	ts := httptest.NewServer(http.HandlerFunc(server.Server.WatchHandler))
+	t.Cleanup(ts.Close)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.WriteJSON(WatchRequest{Session: session}))
+	return conn
+}
+
+// Turn.NewCard is an interface, so decode only the parts the tests look at.
+type testWatchMessage struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Turn   *struct {
+		ID     int    `json:"id"`
+		Player string `json:"player"`
+	} `json:"turn"`
+	State struct {
+		State      GameState         `json:"state"`
+		Turns      []json.RawMessage `json:"turns"`
+		TurnCursor int               `json:"turn_cursor"`
+	} `json:"state"`
+}
+
+func readWatch(t *testing.T, conn *websocket.Conn) testWatchMessage {
+	var msg testWatchMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "ok", msg.Status, "%v", msg.Reason)
+	return msg
+}
+
+func TestWatch_PushesTurns(t *testing.T) {
+	server, players := setupTest(t, 2)
+	conn := dialWatch(t, server, players[1].Session)
+
+	msg := readWatch(t, conn)
+	require.Nil(t, msg.Turn)
+	require.Equal(t, WaitingForTurn, msg.State.State)
+
+	one := 1
+	require.NoError(t, players[0].Move(Move{Type: Play, CardID: &one}))
+	msg = readWatch(t, conn)
+	require.NotNil(t, msg.Turn)
+	require.Equal(t, 0, msg.Turn.ID)
+	require.Equal(t, players[0].Name, msg.Turn.Player)
+	require.Equal(t, YourTurn, msg.State.State)
+	require.Equal(t, 1, msg.State.TurnCursor)
+	require.Len(t, msg.State.Turns, 1)
+
+	eight := 8
+	require.NoError(t, players[1].Move(Move{Type: Discard, CardID: &eight}))
+	msg = readWatch(t, conn)
+	require.NotNil(t, msg.Turn)
+	require.Equal(t, 1, msg.Turn.ID)
+	require.Equal(t, WaitingForTurn, msg.State.State)
+}
+
+func TestWatch_GameStart(t *testing.T) {
+	server := newTestServer(t)
+	server.StartGame()
+	p1 := server.newTestPlayer()
+	conn := dialWatch(t, server, p1.Session)
+
+	msg := readWatch(t, conn)
+	require.Equal(t, NotStarted, msg.State.State)
+
+	server.newTestPlayer()
+	msg = readWatch(t, conn)
+	require.Nil(t, msg.Turn)
+	require.Equal(t, YourTurn, msg.State.State)
+}
+
+func TestWatch_BadSession(t *testing.T) {
+	server, _ := setupTest(t, 2)
+	conn := dialWatch(t, server, "not-a-session")
+
+	var msg testWatchMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "error", msg.Status)
+}